@@ -0,0 +1,80 @@
+package main
+
+import (
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	"github.com/kaist2025/k8s-e2e-tests/internal/collector"
+	"github.com/kaist2025/k8s-e2e-tests/internal/k8sclient"
+)
+
+// customKinds returns the kinds to watch with dynamic informers: the
+// Gateway API plus the resources of cfg (which may be nil), and the
+// resource names cfg fixes.
+func customKinds(cfg *collector.CRDConfig) (map[string]schema.GroupVersionKind, map[string]string) {
+	kinds := map[string]schema.GroupVersionKind{}
+	for kind, gvk := range collector.GatewayAPIKinds {
+		kinds[kind] = gvk
+	}
+	names := map[string]string{}
+	if cfg != nil {
+		for _, cr := range cfg.Resources {
+			kinds[cr.Kind] = cr.GVK()
+			if cr.Resource != "" {
+				names[cr.Kind] = cr.Resource
+			}
+		}
+	}
+	return kinds, names
+}
+
+// watchCustom registers a dynamic informer for every kind the cluster
+// serves, feeding both the Lister's cache and the event handlers. Kinds
+// whose CRDs are not installed are skipped. The returned factory must be
+// started and synced like the typed one.
+func watchCustom(cfg *rest.Config, cs kubernetes.Interface, cli *k8sclient.Lister, coll *collector.Collector,
+	trigger func(), resync time.Duration, kinds map[string]schema.GroupVersionKind, names map[string]string) dynamicinformer.DynamicSharedInformerFactory {
+	dyn, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		log.Printf("custom resources disabled: %v", err)
+		return nil
+	}
+	factory := dynamicinformer.NewDynamicSharedInformerFactory(dyn, resync)
+
+	sorted := make([]string, 0, len(kinds))
+	for kind := range kinds {
+		sorted = append(sorted, kind)
+	}
+	sort.Strings(sorted)
+	for _, kind := range sorted {
+		gvk := kinds[kind]
+		resource := names[kind]
+		if resource == "" {
+			list, err := cs.Discovery().ServerResourcesForGroupVersion(gvk.GroupVersion().String())
+			if err != nil {
+				continue // CRD 미설치
+			}
+			for _, res := range list.APIResources {
+				if res.Kind == gvk.Kind && !strings.Contains(res.Name, "/") {
+					resource = res.Name
+				}
+			}
+			if resource == "" {
+				continue
+			}
+		}
+		inf := factory.ForResource(gvk.GroupVersion().WithResource(resource)).Informer()
+		cli.WatchCustom(gvk, inf)
+		inf.AddEventHandler(makeHandler(kind, coll, trigger))
+		log.Printf("watching %s (%s)", kind, gvk.GroupVersion())
+	}
+	return factory
+}
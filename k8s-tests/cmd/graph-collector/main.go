@@ -2,9 +2,8 @@ package main
 
 import (
 	"context"
-	"encoding/csv"
-	"flag"
 	"fmt"
+	"flag"
 	"log"
 	"os"
 	"path/filepath"
@@ -24,7 +23,6 @@ import (
 	//discoveryv1 "k8s.io/api/discovery/v1"
     "k8s.io/apimachinery/pkg/api/meta"
 
-	"sigs.k8s.io/e2e-framework/pkg/envconf"
 
 	"github.com/kaist2025/k8s-e2e-tests/internal/collector"
 	"github.com/kaist2025/k8s-e2e-tests/internal/exporter"
@@ -32,17 +30,82 @@ import (
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "reach":
+			os.Exit(runReach(os.Args[2:], os.Stdout, os.Stderr))
+		case "permissions":
+			os.Exit(runPermissions(os.Args[2:], os.Stdout, os.Stderr))
+		}
+	}
+
 	var kubeconfig string
 	var resyncPeriod time.Duration
 	var debounce time.Duration
 	var outputDir string
+	var onError string
+	var fromDump string
+	var crdConfig string
+	var keystoneCatalog string
+	var syncTimeout time.Duration
 
 	flag.StringVar(&kubeconfig, "kubeconfig", "", "Absolute path to the kubeconfig file")
 	flag.DurationVar(&resyncPeriod, "resync", time.Hour, "Shared informer resync period")
 	flag.DurationVar(&debounce, "debounce", 5*time.Second, "Debounce interval for saving graph")
 	flag.StringVar(&outputDir, "output", "artifacts", "Directory to write graph outputs")
+	flag.StringVar(&onError, "on-error", "abort", "What to do when a stage fails: abort or continue with a degraded graph")
+	flag.StringVar(&fromDump, "from-dump", "", "Build the graph once from kubectl JSON dumps (file or directory) instead of a live cluster")
+	flag.StringVar(&crdConfig, "crd-config", "", "YAML file declaring custom resources to collect and field rules that link them")
+	flag.StringVar(&keystoneCatalog, "keystone-catalog", "", "Keystone service catalog (JSON file or /v3/auth/catalog URL, token from $OS_TOKEN) to link OpenStack services to Ingresses and Services; read once at startup, an unreachable catalog only degrades the graph")
+	flag.DurationVar(&syncTimeout, "sync-timeout", 2*time.Minute, "How long to wait for the informer caches; kinds not synced by then (e.g. not listable by the service account) fail like a list error")
 	flag.Parse()
 
+	policy, err := collector.ParseFailurePolicy(onError)
+	if err != nil {
+		log.Fatal(err)
+	}
+	stages := []collector.Stage{
+		collector.WorkloadStage,
+		collector.IngressStage,
+		collector.GatewayStage,
+		collector.EndpointStage,
+		collector.DSSTSStage,
+		collector.PVCStage,
+		collector.NetpolStage,
+		collector.JobStage,
+		collector.ConfigSecretStage,
+		collector.DependencyStage,
+		collector.EntrypointStage,
+		collector.ServiceAccountStage,
+		collector.OwnershipStage,
+		collector.HelmStage,
+		collector.OpenStackStage,
+		collector.AutoscalingStage,
+		collector.TopologyStage,
+		collector.RBACStage,
+	}
+	var crds *collector.CRDConfig
+	if crdConfig != "" {
+		if crds, err = collector.LoadCRDConfig(crdConfig); err != nil {
+			log.Fatal(err)
+		}
+		stage, err := collector.CRDStage(crds)
+		if err != nil {
+			log.Fatal(err)
+		}
+		stages = append(stages, stage)
+	}
+	if keystoneCatalog != "" {
+		stages = append(stages, collector.KeystoneCatalogStage(keystoneCatalog))
+	}
+
+	if fromDump != "" {
+		if err := runOffline(fromDump, stages, policy, outputDir); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	configPath := kubeconfig
 	if configPath == "" {
 		configPath = clientcmd.RecommendedHomeFile
@@ -56,21 +119,11 @@ func main() {
 		log.Fatalf("error creating kubernetes client: %v", err)
 	}
 
-	envCfg := envconf.NewWithKubeConfig(configPath)
-	k8sCli := k8sclient.NewFromEnv(envCfg)
-	coll := collector.NewCollector(k8sCli, []collector.Stage{
-		collector.WorkloadStage,
-		collector.IngressStage,
-		collector.EndpointStage,
-		collector.DSSTSStage,
-		collector.PVCStage,
-		collector.NetpolStage,
-		collector.JobStage,
-		collector.ConfigSecretStage,
-		collector.ServiceAccountStage,
-	})
-
+	// 스테이지는 informer 캐시에서 읽으므로 factory.Start 이전에 등록해야 함
 	factory := informers.NewSharedInformerFactory(clientset, resyncPeriod)
+	k8sCli := k8sclient.NewFromInformers(factory)
+	coll := collector.NewCollector(k8sCli, stages)
+	coll.Policy = policy
 
 	triggerCh := make(chan struct{}, 1)
 	debounced := time.AfterFunc(debounce, func() {})
@@ -90,12 +143,25 @@ func main() {
 		{factory.Apps().V1().Deployments().Informer(), "Deployment"},
 		{factory.Core().V1().Services().Informer(), "Service"},
 		{factory.Networking().V1().Ingresses().Informer(), "Ingress"},
+		{factory.Networking().V1().IngressClasses().Informer(), "IngressClass"},
 		{factory.Networking().V1().NetworkPolicies().Informer(), "NetworkPolicy"},
-		{factory.Core().V1().PersistentVolumeClaims().Informer(), "PVC"},
-		{factory.Core().V1().PersistentVolumes().Informer(), "PV"},
+		{factory.Core().V1().PersistentVolumeClaims().Informer(), "PersistentVolumeClaim"},
+		{factory.Core().V1().PersistentVolumes().Informer(), "PersistentVolume"},
 		{factory.Discovery().V1().EndpointSlices().Informer(), "EndpointSlice"},
 		{factory.Apps().V1().DaemonSets().Informer(), "DaemonSet"},
 		{factory.Apps().V1().StatefulSets().Informer(), "StatefulSet"},
+		{factory.Apps().V1().ReplicaSets().Informer(), "ReplicaSet"},
+		{factory.Apps().V1().ControllerRevisions().Informer(), "ControllerRevision"},
+		{factory.Batch().V1().CronJobs().Informer(), "CronJob"},
+		{factory.Autoscaling().V2().HorizontalPodAutoscalers().Informer(), "HorizontalPodAutoscaler"},
+		{factory.Policy().V1().PodDisruptionBudgets().Informer(), "PodDisruptionBudget"},
+		{factory.Core().V1().Nodes().Informer(), "Node"},
+		{factory.Core().V1().Namespaces().Informer(), "Namespace"},
+		{factory.Rbac().V1().Roles().Informer(), "Role"},
+		{factory.Rbac().V1().ClusterRoles().Informer(), "ClusterRole"},
+		{factory.Rbac().V1().RoleBindings().Informer(), "RoleBinding"},
+		{factory.Rbac().V1().ClusterRoleBindings().Informer(), "ClusterRoleBinding"},
+		{factory.Storage().V1().VolumeAttachments().Informer(), "VolumeAttachment"},
 		{factory.Core().V1().Events().Informer(), "Event"}, 
 		{factory.Batch().V1().Jobs().Informer(), "Job"},
 		{factory.Core().V1().ConfigMaps().Informer(), "ConfigMap"},
@@ -105,6 +171,8 @@ func main() {
 	for _, r := range resources {
 		r.informer.AddEventHandler(makeHandler(r.kind, coll, trigger))
 	}
+	kinds, names := customKinds(crds)
+	dynFactory := watchCustom(restCfg, clientset, k8sCli, coll, trigger, resyncPeriod, kinds, names)
 
 	stopCh := make(chan struct{})
 	signalCh := make(chan os.Signal, 1)
@@ -117,15 +185,19 @@ func main() {
 	}()
 
 	factory.Start(stopCh)
-	factory.WaitForCacheSync(stopCh)
+	waitForSync(factory.WaitForCacheSync, stopCh, syncTimeout)
+	if dynFactory != nil {
+		dynFactory.Start(stopCh)
+		waitForSync(dynFactory.WaitForCacheSync, stopCh, syncTimeout)
+	}
 /*
 	ctx := context.Background()
 	log.Println("▶ initial graph collection")
 	if _, err := coll.Run(ctx); err != nil {
 		log.Fatalf("initial run error: %v", err)
 	}
-	saveMermaid(coll.Graph, outputDir)
-	saveCSV(coll.Graph, outputDir)
+	saveMermaid(coll.Graph.Snapshot(), outputDir)
+	saveCSV(coll.Graph.Snapshot(), outputDir)
 
 	for {
 		select {
@@ -133,18 +205,20 @@ func main() {
 			debounced.Reset(debounce)
 		case <-debounced.C:
 			log.Println("⏱ writing updated graph")
-			saveMermaid(coll.Graph, outputDir)
-			saveCSV(coll.Graph, outputDir)
+			saveMermaid(coll.Graph.Snapshot(), outputDir)
+			saveCSV(coll.Graph.Snapshot(), outputDir)
 		case <-stopCh:
 			log.Println("⏱ writing updated graph")
-			saveMermaid(coll.Graph, outputDir)
-			saveCSV(coll.Graph, outputDir)
+			saveMermaid(coll.Graph.Snapshot(), outputDir)
+			saveCSV(coll.Graph.Snapshot(), outputDir)
 			return
 		}
 	}
 		*/
 
 	ctx := context.Background()
+	// 핸들러는 이벤트를 큐에 넣기만 하고, 첫 Run 이후 한 worker가 순서대로 적용
+	go coll.Process(ctx)
 		
 	neo4jUri := "bolt://localhost:7687"
 	neo4jUser := "neo4j"
@@ -154,10 +228,14 @@ func main() {
 	defer driver.Close(ctx)
 	
 	log.Println("▶ initial graph collection")
-	if _, err := coll.Run(ctx); err != nil {
+	res, err := coll.Run(ctx)
+	if err != nil {
 		log.Fatalf("initial run error: %v", err)
 	}
-	if err := exporter.ExportToNeo4j(ctx, coll.Graph, driver); err != nil {
+	if res.Degraded() {
+		log.Printf("⚠ graph is degraded, failed kinds: %v", res.FailedKinds())
+	}
+	if err := exporter.ExportToNeo4j(ctx, coll.Graph.Snapshot(), driver); err != nil {
 		log.Fatalf("neo4j export failed: %v", err)
 	}
 
@@ -167,12 +245,12 @@ func main() {
 			debounced.Reset(debounce)
 		case <-debounced.C:
 			log.Println("⏱ writing updated graph")
-			if err := exporter.ExportToNeo4j(ctx, coll.Graph, driver); err != nil {
+			if err := exporter.ExportToNeo4j(ctx, coll.Graph.Snapshot(), driver); err != nil {
 				log.Printf("neo4j export failed: %v", err)
 			}
 		case <-stopCh:
 			log.Println("⏱ writing final graph")
-			if err := exporter.ExportToNeo4j(ctx, coll.Graph, driver); err != nil {
+			if err := exporter.ExportToNeo4j(ctx, coll.Graph.Snapshot(), driver); err != nil {
 				log.Printf("neo4j export failed: %v", err)
 			}
 			return
@@ -237,13 +315,13 @@ func logEvent(kind, event string, obj interface{}) {
                 svc.Spec.Type, svc.Spec.ClusterIP, svc.Spec.Selector)
         }
 
-    case "PVC":
+    case "PersistentVolumeClaim":
         if pvc, ok := obj.(*corev1.PersistentVolumeClaim); ok {
             log.Printf("-- PVC Phase=%s, Volume=%s, Requested=%v",
                 pvc.Status.Phase, pvc.Spec.VolumeName, pvc.Spec.Resources.Requests.Storage().String())
         }
 
-    case "PV":
+    case "PersistentVolume":
         if pv, ok := obj.(*corev1.PersistentVolume); ok {
             log.Printf("-- PV Phase=%s, Capacity=%v, ReclaimPolicy=%s",
                 pv.Status.Phase, pv.Spec.Capacity.Storage().String(), pv.Spec.PersistentVolumeReclaimPolicy)
@@ -359,7 +437,7 @@ func makeHandler(kind string, coll *collector.Collector, trigger func()) cache.R
 				handleEvent(obj)
 			} else {
 				logEvent(kind, "add", obj)
-				coll.ApplyEvent(kind, "add", obj)
+				coll.Enqueue(kind, "add", obj)
 				trigger()
 			}
 		},
@@ -368,7 +446,7 @@ func makeHandler(kind string, coll *collector.Collector, trigger func()) cache.R
 				handleEvent(newObj)
 			} else {
 				logEvent(kind, "update", newObj)
-				coll.ApplyEvent(kind, "update", newObj)
+				coll.Enqueue(kind, "update", newObj)
 				trigger()
 			}
 		},
@@ -377,13 +455,55 @@ func makeHandler(kind string, coll *collector.Collector, trigger func()) cache.R
 				return
 			}
 			logEvent(kind, "delete", obj)
-			coll.ApplyEvent(kind, "delete", obj)
+			coll.Enqueue(kind, "delete", obj)
 			trigger()
 		},
 	}
 }
 
-func saveMermaid(g *collector.Graph, dir string) error {
+// waitForSync waits for the caches of a started informer factory until
+// stop closes or timeout passes, and logs the informers that have not
+// synced. Their kinds stay unsynced in the Lister, so stages report them as
+// list errors and Run degrades or aborts according to -on-error.
+func waitForSync[K comparable](wait func(stop <-chan struct{}) map[K]bool, stop <-chan struct{}, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	go func() {
+		select {
+		case <-stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	for informer, synced := range wait(ctx.Done()) {
+		if !synced {
+			log.Printf("⚠ %v: cache not synced after %s", informer, timeout)
+		}
+	}
+}
+
+// runOffline builds the graph once from captured dumps and writes the
+// Mermaid and CSV exports to dir.
+func runOffline(path string, stages []collector.Stage, policy collector.FailurePolicy, dir string) error {
+	cli, err := k8sclient.NewFromDump(path)
+	if err != nil {
+		return fmt.Errorf("load dump: %w", err)
+	}
+	coll := collector.NewCollector(cli, stages)
+	coll.Policy = policy
+	res, err := coll.Run(context.Background())
+	if err != nil {
+		return err
+	}
+	snap := res.Graph.Snapshot()
+	log.Printf("▶ offline graph from %s: nodes=%d edges=%d", path, len(snap.Nodes), len(snap.Edges))
+	if err := saveMermaid(snap, dir); err != nil {
+		return err
+	}
+	return saveCSV(snap, dir)
+}
+
+func saveMermaid(g *collector.Snapshot, dir string) error {
 	err := os.MkdirAll(dir, 0o755)
 	if err != nil {
 		return err
@@ -393,41 +513,25 @@ func saveMermaid(g *collector.Graph, dir string) error {
 		return err
 	}
 	defer f.Close()
-	fmt.Fprintln(f, "graph LR")
-	for _, e := range g.Edges {
-		fmt.Fprintf(f, "%s[\"%s\"] -->|%s| %s[\"%s\"]\n",
-			e.From, g.Nodes[e.From].Label, e.Kind, e.To, g.Nodes[e.To].Label)
-	}
-	return nil
+	return exporter.WriteMermaid(g, f)
 }
 
-func saveCSV(g *collector.Graph, dir string) error {
+func saveCSV(g *collector.Snapshot, dir string) error {
 	if err := os.MkdirAll(dir, 0o755); err != nil {
 		return err
 	}
-	fn1 := filepath.Join(dir, "nodes.csv")
-	f1, err := os.Create(fn1)
+	f1, err := os.Create(filepath.Join(dir, "nodes.csv"))
 	if err != nil {
 		return err
 	}
 	defer f1.Close()
-	w1 := csv.NewWriter(f1)
-	w1.Write([]string{"UID", "Label", "Type", "NS"})
-	for _, n := range g.Nodes {
-		w1.Write([]string{n.UID, n.Label, n.Type, n.NS})
-	}
-	w1.Flush()
-	fn2 := filepath.Join(dir, "edges.csv")
-	f2, err := os.Create(fn2)
+	if err := exporter.WriteNodesCSV(g, f1); err != nil {
+		return err
+	}
+	f2, err := os.Create(filepath.Join(dir, "edges.csv"))
 	if err != nil {
 		return err
 	}
 	defer f2.Close()
-	w2 := csv.NewWriter(f2)
-	w2.Write([]string{"FromUID", "ToUID", "Kind"})
-	for _, e := range g.Edges {
-		w2.Write([]string{e.From, e.To, string(e.Kind)})
-	}
-	w2.Flush()
-	return nil
+	return exporter.WriteEdgesCSV(g, f2)
 }
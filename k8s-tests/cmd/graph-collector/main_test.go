@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/kaist2025/k8s-e2e-tests/internal/collector"
+	"github.com/kaist2025/k8s-e2e-tests/internal/exporter"
+)
+
+// TestWatchLoopExportsWhileHandlersMutate drives real informer handlers
+// against a fake clientset while the main loop exports snapshots. Run with
+// -race.
+func TestWatchLoopExportsWhileHandlersMutate(t *testing.T) {
+	ctx := context.Background()
+	clientset := fake.NewSimpleClientset()
+	factory := informers.NewSharedInformerFactory(clientset, 0)
+	coll := collector.NewCollector(nil, nil)
+
+	triggerCh := make(chan struct{}, 1)
+	trigger := func() {
+		select {
+		case triggerCh <- struct{}{}:
+		default:
+		}
+	}
+	factory.Core().V1().Pods().Informer().AddEventHandler(makeHandler("Pod", coll, trigger))
+	factory.Core().V1().Services().Informer().AddEventHandler(makeHandler("Service", coll, trigger))
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	factory.Start(stopCh)
+	factory.WaitForCacheSync(stopCh)
+	if _, err := coll.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+	procCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go coll.Process(procCtx)
+
+	const n = 50
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		pods := clientset.CoreV1().Pods("openstack")
+		for i := 0; i < n; i++ {
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("nova-%d", i), Labels: map[string]string{"app": "nova"}}}
+			if _, err := pods.Create(ctx, pod, metav1.CreateOptions{}); err != nil {
+				t.Error(err)
+				return
+			}
+			if i%5 == 0 {
+				_ = pods.Delete(ctx, pod.Name, metav1.DeleteOptions{})
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		svcs := clientset.CoreV1().Services("openstack")
+		for i := 0; i < n; i++ {
+			svc := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("nova-%d", i)}}
+			if _, err := svcs.Create(ctx, svc, metav1.CreateOptions{}); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+
+	// main loop 역할: 핸들러가 도는 동안 snapshot을 계속 export
+	done := make(chan struct{})
+	go func() { wg.Wait(); close(done) }()
+	export := func() {
+		snap := coll.Graph.Snapshot()
+		if err := exporter.WriteMermaid(snap, io.Discard); err != nil {
+			t.Fatal(err)
+		}
+		if err := exporter.WriteNodesCSV(snap, io.Discard); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for running := true; running; {
+		select {
+		case <-triggerCh:
+			export()
+		case <-done:
+			running = false
+		}
+	}
+
+	want := n + n - n/5 // services + surviving pods
+	deadline := time.Now().Add(5 * time.Second)
+	for coll.Graph.NodeCount() != want {
+		if time.Now().After(deadline) {
+			t.Fatalf("nodes = %d, want %d", coll.Graph.NodeCount(), want)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	export()
+}
+
+// A graph saved by the offline run must answer reach queries the same way
+// the dump it was built from does.
+func TestReachFromSavedGraph(t *testing.T) {
+	dump := "../../internal/collector/testdata/netpol.yaml"
+	dir := t.TempDir()
+	if err := runOffline(dump, collector.ReachStages, collector.AbortOnError, dir); err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		args []string
+		code int
+	}{
+		{[]string{"-from", "pod/openstack/keystone-api-0", "-to", "svc/openstack/mariadb", "-port", "3306"}, 0},
+		{[]string{"-from", "pod/openstack/nova-api-0", "-to", "svc/openstack/mariadb", "-port", "3306"}, 1},
+		{[]string{"-from", "pod/openstack/missing", "-to", "svc/openstack/mariadb"}, 2},
+	} {
+		var saved, live strings.Builder
+		code := runReach(append([]string{"-graph", dir}, tc.args...), &saved, io.Discard)
+		if code != tc.code {
+			t.Errorf("%v: exit %d, want %d\n%s", tc.args, code, tc.code, saved.String())
+		}
+		runReach(append([]string{"-from-dump", dump}, tc.args...), &live, io.Discard)
+		if saved.String() != live.String() {
+			t.Errorf("%v: saved graph says\n%s\ndump says\n%s", tc.args, saved.String(), live.String())
+		}
+	}
+}
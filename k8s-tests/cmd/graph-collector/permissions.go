@@ -0,0 +1,51 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/kaist2025/k8s-e2e-tests/internal/collector"
+)
+
+// runPermissions implements `graph-collector permissions`: what a pod's
+// service account may do against the apiserver. Broad grants (wildcards,
+// reading secrets everywhere) are marked with "!". The exit code is 0, 1
+// when some grant is broad, and 2 on errors.
+func runPermissions(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("permissions", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	pod := fs.String("pod", "", "Pod as namespace/name")
+	graphDir := fs.String("graph", "", "Answer from a saved graph (directory holding nodes.csv and edges.csv)")
+	fromDump := fs.String("from-dump", "", "Answer from kubectl JSON dumps (file or directory)")
+	kubeconfig := fs.String("kubeconfig", "", "Absolute path to the kubeconfig file")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	ns, name, ok := strings.Cut(*pod, "/")
+	if !ok || ns == "" || name == "" {
+		fmt.Fprintln(stderr, "permissions: -pod namespace/name is required")
+		return 2
+	}
+
+	snap, err := querySnapshot(collector.PermissionStages, *graphDir, *fromDump, *kubeconfig)
+	if err != nil {
+		fmt.Fprintf(stderr, "permissions: %v\n", err)
+		return 2
+	}
+	perms, err := snap.PodPermissions(ns, name)
+	if err != nil {
+		fmt.Fprintf(stderr, "permissions: %v\n", err)
+		return 2
+	}
+	code := 0
+	for _, p := range perms {
+		mark := " "
+		if p.Broad() {
+			mark, code = "!", 1
+		}
+		fmt.Fprintf(stdout, "%s %s\n", mark, p)
+	}
+	return code
+}
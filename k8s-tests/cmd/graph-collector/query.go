@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/e2e-framework/klient/k8s/resources"
+
+	"github.com/kaist2025/k8s-e2e-tests/internal/collector"
+	"github.com/kaist2025/k8s-e2e-tests/internal/exporter"
+	"github.com/kaist2025/k8s-e2e-tests/internal/k8sclient"
+)
+
+// querySnapshot returns the graph the query subcommands answer from: a
+// saved graph when graphDir is set, otherwise stages run once over the
+// dump at fromDump or over the live cluster.
+func querySnapshot(stages []collector.Stage, graphDir, fromDump, kubeconfig string) (*collector.Snapshot, error) {
+	if graphDir != "" {
+		return loadCSV(graphDir)
+	}
+	var cli collector.Client
+	if fromDump != "" {
+		dump, err := k8sclient.NewFromDump(fromDump)
+		if err != nil {
+			return nil, fmt.Errorf("load dump: %w", err)
+		}
+		cli = dump
+	} else {
+		if kubeconfig == "" {
+			kubeconfig = clientcmd.RecommendedHomeFile
+		}
+		restCfg, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
+		if err != nil {
+			return nil, fmt.Errorf("load kubeconfig: %w", err)
+		}
+		res, err := resources.New(restCfg)
+		if err != nil {
+			return nil, err
+		}
+		cli = k8sclient.New(res)
+	}
+	res, err := collector.NewCollector(cli, stages).Run(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	return res.Graph.Snapshot(), nil
+}
+
+// loadCSV reads the nodes.csv and edges.csv that saveCSV writes.
+func loadCSV(dir string) (*collector.Snapshot, error) {
+	nodes, err := os.Open(filepath.Join(dir, "nodes.csv"))
+	if err != nil {
+		return nil, err
+	}
+	defer nodes.Close()
+	edges, err := os.Open(filepath.Join(dir, "edges.csv"))
+	if err != nil {
+		return nil, err
+	}
+	defer edges.Close()
+	return exporter.ReadCSV(nodes, edges)
+}
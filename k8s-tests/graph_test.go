@@ -8,6 +8,7 @@ import (
     "testing"
 
     "github.com/kaist2025/k8s-e2e-tests/internal/collector"
+    "github.com/kaist2025/k8s-e2e-tests/internal/exporter"
     "github.com/kaist2025/k8s-e2e-tests/internal/k8sclient"
 
     "sigs.k8s.io/e2e-framework/klient/conf"     
@@ -22,7 +23,8 @@ func TestStaticGraph(t *testing.T) {
 	cfg      := envconf.NewWithKubeConfig(kubePath)
 
 	// 2) k8sclient 생성
-	cli := k8sclient.NewFromEnv(cfg)   
+	cli, err := k8sclient.NewFromEnv(cfg)
+	if err != nil { t.Fatalf("client: %v", err) }
 	if ns := os.Getenv("NAMESPACE"); ns != "" {
 		cli = cli.Namespace(ns)
 	}
@@ -42,8 +44,9 @@ func TestStaticGraph(t *testing.T) {
         },
     }
 
-    g, err := col.Run(ctx)
+    res, err := col.Run(ctx)
     if err != nil { t.Fatalf("collect: %v", err) }
+    snap := res.Graph.Snapshot()
 
     // ── Mermaid Graph 저장 
     dir, _ := filepath.Abs("artifacts")
@@ -51,9 +54,9 @@ func TestStaticGraph(t *testing.T) {
     f, _ := os.Create(filepath.Join(dir, "static_v3.mmd"))
     defer f.Close()
     fmt.Fprintln(f, "graph LR")
-    for _, e := range g.Edges {
+    for _, e := range snap.Edges {
         fmt.Fprintf(f, `%s["%s"] -->|%s| %s["%s"]`+"\n",
-                e.From, g.Nodes[e.From].Label,  
-                e.Kind, e.To, g.Nodes[e.To].Label)
+                exporter.MermaidID(e.From), snap.Nodes[e.From].Label,  
+                e.Kind, exporter.MermaidID(e.To), snap.Nodes[e.To].Label)
     }
 }
\ No newline at end of file
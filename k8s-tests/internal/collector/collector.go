@@ -2,59 +2,115 @@ package collector
 
 import (
     "context"  
+    "errors"
+    "fmt"
     "log"
+    "sync"
 
     "k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
     "github.com/kaist2025/k8s-e2e-tests/internal/k8sclient"
 )
 
+// Client is the cluster view stages read from (see k8sclient.Client).
 type Client = k8sclient.Client
 
-type Stage func(ctx context.Context, c *Client, g *Graph) error
+type Stage func(ctx context.Context, c Client, g *Graph) error
 
 type Collector struct {
-    Client *Client
+    Client Client
     Stages []Stage
     Graph  *Graph
+    Policy FailurePolicy // zero value aborts on the first failing stage
+
+    mu    sync.Mutex    // 이벤트 적용과 Run의 교체를 직렬화
+    ran   bool          // 첫 Run이 끝나기 전에는 Relink하지 않음
+    ready chan struct{} // 첫 Run이 끝나면 닫힘
+    queue eventQueue
 }
 
-func NewCollector(client *Client, stages []Stage) *Collector {
+func NewCollector(client Client, stages []Stage) *Collector {
     return &Collector{
         Client: client,
         Stages: stages,
-        Graph: &Graph{
-            Nodes:   make(map[string]Node),
-            Edges:   make(map[string]Edge),
-            EdgeMap: make(map[string]map[string]struct{}),
-        },
+        Graph:  NewGraph(),
     }
 }
 
-func (co *Collector) Run(ctx context.Context) (*Graph, error) {
+// Run collects a fresh graph. Stage failures are recorded in the result;
+// with AbortOnError the first one ends the run and co.Graph keeps its
+// previous contents, with ContinueOnError the remaining stages still run and
+// the degraded graph is published. A DegradedError never ends the run.
+func (co *Collector) Run(ctx context.Context) (*RunResult, error) {
     // Node 구조체를 담을 맵으로 초기화
-    g := &Graph{
-        Nodes:   make(map[string]Node),
-        Edges:   make(map[string]Edge),
-        EdgeMap: make(map[string]map[string]struct{}),
-    }
+    g := NewGraph()
+    res := &RunResult{}
 
     for _, st := range co.Stages {
         if err := st(ctx, co.Client, g); err != nil {
-            return nil, err
+            f := newStageFailure(st, err)
+            res.Failures = append(res.Failures, f)
+            var degraded *DegradedError
+            if co.Policy == AbortOnError && !errors.As(err, &degraded) {
+                return res, fmt.Errorf("%s: %w", f.Stage, err)
+            }
+            log.Printf("[Collector] %s degraded: %v", f.Stage, err)
         }
     }
-    co.Graph = g
-    return g, nil
+    // informer 핸들러가 co.Graph를 계속 참조하므로 포인터 대신 내용을 교체
+    co.mu.Lock()
+    defer co.mu.Unlock()
+    if co.Graph == nil {
+        co.Graph = g
+    } else {
+        co.Graph.Replace(g)
+    }
+    if !co.ran {
+        co.ran = true
+        close(co.readyLocked())
+    }
+    res.Graph = co.Graph
+    return res, nil
 }
 
+// ApplyEvent applies one informer event to the graph right away. Informer
+// handlers should use Enqueue, which applies events from a single worker.
+// Before the first Run only the node is updated: Run rebuilds the edges
+// from the caches anyway.
 func (co *Collector) ApplyEvent(kind string, event string, obj interface{}) {
+	co.mu.Lock()
+	defer co.mu.Unlock()
+	var r *resolver
+	if co.Client != nil {
+		r = newResolver(context.Background(), co.Client, co.Graph)
+	}
+	co.apply(r, kind, event, obj)
+}
+
+// apply is ApplyEvent with the caller's resolver, or none to skip the
+// relink. The caller holds co.mu.
+func (co *Collector) apply(r *resolver, kind string, event string, obj interface{}) {
 	u, err := toUnstructured(obj)
 	if err != nil {
 		log.Printf("invalid object type in event '%s' for kind '%s': %T\n", event, kind, obj)
 		return
 	}
 
+	// 초기 동기화와 resync는 그래프에 이미 반영된 객체를 다시 보내므로 건너뜀
+	if rv := u.GetResourceVersion(); event != "delete" && rv != "" {
+		if n, ok := co.Graph.Lookup(kind, u.GetNamespace(), u.GetName()); ok && n.Props["resourceVersion"] == rv {
+			return
+		}
+	}
+	// 삭제되면 노드와 함께 edge도 사라지므로 이웃의 파생 속성 갱신용으로 미리 기록
+	var linked []string
+	if event == "delete" {
+		uid := KeyOf(kind, u.GetNamespace(), u.GetName()).ID()
+		for _, e := range co.Graph.Incident(uid) {
+			linked = append(linked, e.From, e.To)
+		}
+	}
+
 	switch event {
 	case "add":
 		HandleAdd(co.Graph, kind, u)
@@ -63,6 +119,13 @@ func (co *Collector) ApplyEvent(kind string, event string, obj interface{}) {
 	case "delete":
 		HandleDelete(co.Graph, kind, u)
 	}
+	// 노드만 갱신하면 새 Pod의 owns/routes 등이 재시작 전까지 빠지므로
+	// 관련 relation을 다시 계산
+	if r != nil && co.ran {
+		if err := r.relink(kind, u, event == "delete", linked); err != nil {
+			log.Printf("[Relink] %s %s/%s: edges left unchanged: %v", kind, u.GetNamespace(), u.GetName(), err)
+		}
+	}
 }
 
 func toUnstructured(obj interface{}) (*unstructured.Unstructured, error) {
@@ -0,0 +1,195 @@
+package collector
+
+import (
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// secretProviderClassAPIVersion is the Secrets Store CSI driver API that
+// secretProviderClass volume attributes refer to.
+const secretProviderClassAPIVersion = "secrets-store.csi.x-k8s.io/v1"
+
+// podContainer is a container of any kind in a pod spec.
+type podContainer struct {
+	*corev1.Container
+	role string // "init", "ephemeral" or "" for regular containers
+}
+
+// podContainers lists init, regular and ephemeral containers in that order.
+func podContainers(pod *corev1.Pod) []podContainer {
+	var out []podContainer
+	for i := range pod.Spec.InitContainers {
+		out = append(out, podContainer{&pod.Spec.InitContainers[i], "init"})
+	}
+	for i := range pod.Spec.Containers {
+		out = append(out, podContainer{&pod.Spec.Containers[i], ""})
+	}
+	for i := range pod.Spec.EphemeralContainers {
+		out = append(out, podContainer{(*corev1.Container)(&pod.Spec.EphemeralContainers[i].EphemeralContainerCommon), "ephemeral"})
+	}
+	return out
+}
+
+// configAttrs starts the attributes of a configuration edge: the reading
+// container (if any) and whether the pod starts without the object.
+func configAttrs(c *podContainer, optional *bool) map[string]string {
+	attrs := map[string]string{"optional": strconv.FormatBool(optional != nil && *optional)}
+	if c != nil {
+		attrs["container"] = c.Name
+		if c.role != "" {
+			attrs["containerType"] = c.role
+		}
+	}
+	return attrs
+}
+
+// derivePodConfig links a pod to the ConfigMaps and Secrets it reads, from
+// every container (init and ephemeral included):
+//
+//   - envFrom and env[].valueFrom key refs → Reads {container, key, env}
+//   - configMap, secret and projected volumes → Mounts {container, mountPath, key}
+//   - CSI nodePublishSecretRef → Reads {volume, csiDriver}; Secrets Store
+//     CSI volumes → Mounts to their SecretProviderClass
+//   - imagePullSecrets → Reads {imagePullSecret=true}
+//
+// Every edge carries optional=true|false.
+func derivePodConfig(r *resolver, pod *corev1.Pod) []Edge {
+	podUID := r.node(pod.Namespace, pod.Name, "Pod")
+	containers := podContainers(pod)
+	var out []Edge
+	reads := func(kind, name string, attrs map[string]string) {
+		out = append(out, Edge{From: podUID, To: r.node(pod.Namespace, name, kind), Kind: Reads, Attrs: attrs})
+	}
+
+	for i := range containers {
+		c := &containers[i]
+		// EnvFrom: ConfigMapRef / SecretRef
+		for _, envFrom := range c.EnvFrom {
+			switch {
+			case envFrom.ConfigMapRef != nil:
+				attrs := configAttrs(c, envFrom.ConfigMapRef.Optional)
+				if envFrom.Prefix != "" {
+					attrs["prefix"] = envFrom.Prefix
+				}
+				reads("ConfigMap", envFrom.ConfigMapRef.Name, attrs)
+			case envFrom.SecretRef != nil:
+				attrs := configAttrs(c, envFrom.SecretRef.Optional)
+				if envFrom.Prefix != "" {
+					attrs["prefix"] = envFrom.Prefix
+				}
+				reads("Secret", envFrom.SecretRef.Name, attrs)
+			}
+		}
+		// env[].valueFrom: 키 단위 참조
+		for _, env := range c.Env {
+			if env.ValueFrom == nil {
+				continue
+			}
+			switch ref := env.ValueFrom; {
+			case ref.ConfigMapKeyRef != nil:
+				attrs := configAttrs(c, ref.ConfigMapKeyRef.Optional)
+				attrs["key"], attrs["env"] = ref.ConfigMapKeyRef.Key, env.Name
+				reads("ConfigMap", ref.ConfigMapKeyRef.Name, attrs)
+			case ref.SecretKeyRef != nil:
+				attrs := configAttrs(c, ref.SecretKeyRef.Optional)
+				attrs["key"], attrs["env"] = ref.SecretKeyRef.Key, env.Name
+				reads("Secret", ref.SecretKeyRef.Name, attrs)
+			}
+		}
+	}
+
+	mounts := func(to, volume string, items []corev1.KeyToPath, optional *bool) {
+		for _, attrs := range volumeMountAttrs(containers, volume, items, optional) {
+			out = append(out, Edge{From: podUID, To: to, Kind: Mounts, Attrs: attrs})
+		}
+	}
+	for _, vol := range pod.Spec.Volumes {
+		switch {
+		case vol.ConfigMap != nil:
+			mounts(r.node(pod.Namespace, vol.ConfigMap.Name, "ConfigMap"), vol.Name, vol.ConfigMap.Items, vol.ConfigMap.Optional)
+		case vol.Secret != nil:
+			mounts(r.node(pod.Namespace, vol.Secret.SecretName, "Secret"), vol.Name, vol.Secret.Items, vol.Secret.Optional)
+		case vol.Projected != nil:
+			for _, src := range vol.Projected.Sources {
+				switch {
+				case src.ConfigMap != nil:
+					mounts(r.node(pod.Namespace, src.ConfigMap.Name, "ConfigMap"), vol.Name, src.ConfigMap.Items, src.ConfigMap.Optional)
+				case src.Secret != nil:
+					mounts(r.node(pod.Namespace, src.Secret.Name, "Secret"), vol.Name, src.Secret.Items, src.Secret.Optional)
+				}
+			}
+		case vol.CSI != nil:
+			if ref := vol.CSI.NodePublishSecretRef; ref != nil {
+				attrs := configAttrs(nil, nil)
+				attrs["volume"], attrs["csiDriver"] = vol.Name, vol.CSI.Driver
+				reads("Secret", ref.Name, attrs)
+			}
+			if spc := vol.CSI.VolumeAttributes["secretProviderClass"]; spc != "" {
+				mounts(r.g.AddKey(RefKey(pod.Namespace, secretProviderClassAPIVersion, "SecretProviderClass", spc), ""), vol.Name, nil, nil)
+			}
+		}
+	}
+
+	for _, ips := range pod.Spec.ImagePullSecrets {
+		attrs := configAttrs(nil, nil)
+		attrs["imagePullSecret"] = "true"
+		reads("Secret", ips.Name, attrs)
+	}
+	return out
+}
+
+// volumeMountAttrs returns one attribute set per container mount of volume.
+// The key is what the mount exposes: for a subPath (openstack-helm mounts
+// single config files that way) the keys of the items projected at or under
+// it, or the subPath itself when every key is projected under its own name;
+// for whole-volume mounts the projected item keys.
+func volumeMountAttrs(containers []podContainer, volume string, items []corev1.KeyToPath, optional *bool) []map[string]string {
+	keys := make([]string, 0, len(items))
+	for _, it := range items {
+		keys = append(keys, it.Key)
+	}
+	var out []map[string]string
+	for i := range containers {
+		c := &containers[i]
+		for _, vm := range c.VolumeMounts {
+			if vm.Name != volume {
+				continue
+			}
+			key := vm.SubPath
+			if key == "" {
+				key = strings.Join(keys, ",")
+			} else if len(items) > 0 {
+				key = subPathKeys(items, vm.SubPath)
+			}
+			attrs := configAttrs(c, optional)
+			attrs["mountPath"], attrs["key"] = vm.MountPath, key
+			out = append(out, attrs)
+		}
+	}
+	if len(out) == 0 {
+		// 볼륨은 선언됐지만 어느 컨테이너도 마운트하지 않음
+		attrs := configAttrs(nil, optional)
+		attrs["key"] = strings.Join(keys, ",")
+		out = append(out, attrs)
+	}
+	return out
+}
+
+// subPathKeys maps a subPath, a path inside the volume, back to the keys of
+// the items projected there; items[].path may rename a key or put it in a
+// directory. A subPath no item is projected to is returned as is.
+func subPathKeys(items []corev1.KeyToPath, subPath string) string {
+	dir := strings.TrimSuffix(subPath, "/") + "/"
+	var keys []string
+	for _, it := range items {
+		if it.Path == subPath || strings.HasPrefix(it.Path, dir) {
+			keys = append(keys, it.Key)
+		}
+	}
+	if len(keys) == 0 {
+		return subPath
+	}
+	return strings.Join(keys, ",")
+}
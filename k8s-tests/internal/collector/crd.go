@@ -0,0 +1,301 @@
+package collector
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/util/jsonpath"
+)
+
+// CRDConfig declares the custom resources CRDStage collects and the rules
+// that turn their fields into edges:
+//
+//	resources:
+//	- {group: ceph.rook.io, version: v1, kind: CephCluster}
+//	- {group: ceph.rook.io, version: v1, kind: CephBlockPool}
+//	rules:
+//	- {kind: CephBlockPoolRadosNamespace, path: "{.spec.blockPoolName}", target: CephBlockPool}
+//	- {kind: MariaDB, path: .spec.rootPasswordSecretKeyRef.name, target: Secret, edge: reads}
+//
+// Rule values name objects in the source's namespace unless the target kind
+// is cluster-scoped or the rule fixes a namespace.
+type CRDConfig struct {
+	Resources []CustomResource `json:"resources"`
+	Rules     []FieldRule      `json:"rules"`
+}
+
+// CustomResource is one kind to list and watch. Resource (the plural used
+// in API paths) is looked up through discovery when empty.
+type CustomResource struct {
+	Group         string `json:"group"`
+	Version       string `json:"version"`
+	Kind          string `json:"kind"`
+	Resource      string `json:"resource,omitempty"`
+	ClusterScoped bool   `json:"clusterScoped,omitempty"`
+}
+
+func (cr CustomResource) GVK() schema.GroupVersionKind {
+	return schema.GroupVersionKind{Group: cr.Group, Version: cr.Version, Kind: cr.Kind}
+}
+
+// FieldRule links objects of Kind to the objects named by the values at
+// Path, a JSONPath template ("{.spec.pool}") or plain field path
+// ("spec.pool").
+type FieldRule struct {
+	Kind            string `json:"kind"`
+	Path            string `json:"path"`
+	Target          string `json:"target"`
+	TargetGroup     string `json:"targetGroup,omitempty"`     // default: registered group of Target
+	TargetNamespace string `json:"targetNamespace,omitempty"` // default: the source's namespace
+	Edge            string `json:"edge,omitempty"`            // default: uses
+}
+
+// LoadCRDConfig reads a YAML or JSON CRDConfig and checks its rules.
+func LoadCRDConfig(path string) (*CRDConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cfg CRDConfig
+	dec := utilyaml.NewYAMLOrJSONDecoder(bytes.NewReader(data), 4096)
+	if err := dec.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &cfg, nil
+}
+
+func (cfg *CRDConfig) validate() error {
+	kinds := cfg.Kinds()
+	for i, r := range cfg.Rules {
+		if _, ok := kinds[r.Kind]; !ok {
+			return fmt.Errorf("rule %d: kind %q is not a configured resource", i, r.Kind)
+		}
+		if r.Target == "" {
+			return fmt.Errorf("rule %d: target is required", i)
+		}
+		if _, err := r.parse(); err != nil {
+			return fmt.Errorf("rule %d: path %q: %w", i, r.Path, err)
+		}
+	}
+	return nil
+}
+
+// Kinds maps the configured kinds to their group, version and kind.
+func (cfg *CRDConfig) Kinds() map[string]schema.GroupVersionKind {
+	out := make(map[string]schema.GroupVersionKind, len(cfg.Resources))
+	for _, cr := range cfg.Resources {
+		out[cr.Kind] = cr.GVK()
+	}
+	return out
+}
+
+// Resource returns the configured resource of kind.
+func (cfg *CRDConfig) Resource(kind string) (CustomResource, bool) {
+	for _, cr := range cfg.Resources {
+		if cr.Kind == kind {
+			return cr, true
+		}
+	}
+	return CustomResource{}, false
+}
+
+func (rule FieldRule) parse() (*jsonpath.JSONPath, error) {
+	tmpl := strings.TrimSpace(rule.Path)
+	if !strings.HasPrefix(tmpl, "{") {
+		tmpl = "{." + strings.TrimPrefix(tmpl, ".") + "}"
+	}
+	jp := jsonpath.New(rule.Kind).AllowMissingKeys(true)
+	return jp, jp.Parse(tmpl)
+}
+
+// values returns the non-empty scalar values at the rule's path in u.
+func (rule FieldRule) values(u *unstructured.Unstructured) []string {
+	jp, err := rule.parse()
+	if err != nil {
+		return nil
+	}
+	results, err := jp.FindResults(u.Object)
+	if err != nil {
+		return nil
+	}
+	var out []string
+	for _, res := range results {
+		for _, v := range res {
+			if !v.IsValid() || !v.CanInterface() {
+				continue
+			}
+			switch x := v.Interface().(type) {
+			case string:
+				if x != "" {
+					out = append(out, x)
+				}
+			case nil, map[string]interface{}, []interface{}:
+			default:
+				out = append(out, fmt.Sprint(x))
+			}
+		}
+	}
+	return out
+}
+
+// register makes the configured kinds known to the node identity registry
+// and the event path. The registry is shared by every CRDConfig: a kind
+// registered again must come with the same resource and rules, and a
+// built-in kind keeps its group, or register fails.
+func (cfg *CRDConfig) register() error {
+	registerMu.Lock()
+	defer registerMu.Unlock()
+	for _, cr := range cfg.Resources {
+		sig := cfg.signature(cr)
+		if prev, ok := registered[cr.Kind]; ok {
+			if prev != sig {
+				return fmt.Errorf("kind %s is already registered with different resource or rules", cr.Kind)
+			}
+			continue
+		}
+		if group, ok := kindGroups[cr.Kind]; ok && group != cr.Group {
+			return fmt.Errorf("kind %s is built in with group %q, not %q", cr.Kind, group, cr.Group)
+		}
+	}
+	for _, cr := range cfg.Resources {
+		if _, ok := registered[cr.Kind]; ok {
+			continue
+		}
+		registered[cr.Kind] = cfg.signature(cr)
+		if _, ok := kindGroups[cr.Kind]; !ok {
+			kindGroups[cr.Kind] = cr.Group
+			clusterScoped[cr.Kind] = cr.ClusterScoped
+		}
+		gvk := cr.GVK()
+		list := func(r *resolver) []unstructured.Unstructured { return r.customObjects(cr.Kind, gvk) }
+		owners := newOwnerRelation(cr.Kind, list)
+		extraRelations = append(extraRelations,
+			cfg.relation(cr.Kind, list),
+			owners,
+			partOfRelation(owners))
+	}
+	return nil
+}
+
+// signature identifies cr and the rules on its kind, to tell whether a
+// kind is registered again with the same configuration.
+func (cfg *CRDConfig) signature(cr CustomResource) string {
+	sig := fmt.Sprintf("%+v", cr)
+	for _, rule := range cfg.Rules {
+		if rule.Kind == cr.Kind {
+			sig += fmt.Sprintf(";%+v", rule)
+		}
+	}
+	return sig
+}
+
+var (
+	registered     = map[string]string{} // kind -> signature
+	extraRelations []*relation           // relations of configured custom kinds
+)
+
+// allRelations is relations plus those registered for custom kinds.
+func allRelations() []*relation {
+	registerMu.RLock()
+	defer registerMu.RUnlock()
+	return append(append([]*relation{}, relations...), extraRelations...)
+}
+
+// relation derives the edges of every rule on kind.
+func (cfg *CRDConfig) relation(kind string, list func(r *resolver) []unstructured.Unstructured) *relation {
+	var rules []FieldRule
+	var edges []EdgeKind
+	var targets []string
+	for _, rule := range cfg.Rules {
+		if rule.Kind != kind {
+			continue
+		}
+		rules = append(rules, rule)
+		if ek := rule.edgeKind(); !hasEdgeKind(edges, ek) {
+			edges = append(edges, ek)
+		}
+		targets = append(targets, rule.Target)
+	}
+	return newRelation(strings.ToLower(kind)+"-fields", kind, edges, targets, list,
+		func(r *resolver, u *unstructured.Unstructured) []Edge {
+			from := r.node(u.GetNamespace(), u.GetName(), kind)
+			var out []Edge
+			for _, rule := range rules {
+				for _, name := range rule.values(u) {
+					out = append(out, Edge{
+						From:  from,
+						To:    r.g.AddKey(rule.targetKey(u.GetNamespace(), name), ""),
+						Kind:  rule.edgeKind(),
+						Attrs: map[string]string{"field": rule.Path},
+					})
+				}
+			}
+			return out
+		})
+}
+
+func (rule FieldRule) edgeKind() EdgeKind {
+	if rule.Edge == "" {
+		return Uses
+	}
+	return EdgeKind(rule.Edge)
+}
+
+func (rule FieldRule) targetKey(ns, name string) NodeKey {
+	group := rule.TargetGroup
+	if group == "" {
+		group = GroupForKind(rule.Target)
+	}
+	if rule.TargetNamespace != "" {
+		ns = rule.TargetNamespace
+	}
+	if isClusterScoped(rule.Target) {
+		ns = ""
+	}
+	return NodeKey{Group: group, Kind: rule.Target, Namespace: ns, Name: name}
+}
+
+func (r *resolver) customObjects(kind string, gvk schema.GroupVersionKind) []unstructured.Unstructured {
+	return listed(r, kind, func(ctx context.Context) ([]unstructured.Unstructured, error) {
+		return r.c.Objects(ctx, gvk)
+	})
+}
+
+// CRDStage collects the custom resources of cfg and the edges of its
+// rules, plus the Owns edges from their ownerReferences. It registers the
+// kinds for informer events, so build it before starting the informers.
+// It fails if a kind of cfg was already registered with other rules.
+func CRDStage(cfg *CRDConfig) (Stage, error) {
+	if err := cfg.register(); err != nil {
+		return nil, err
+	}
+	var rels []*relation
+	for _, rel := range allRelations() {
+		if _, ok := cfg.Resource(rel.kind); ok {
+			rels = append(rels, rel)
+		}
+	}
+	return func(ctx context.Context, c Client, g *Graph) error {
+		before := g.EdgeCount()
+		err := newResolver(ctx, c, g).run(rels...)
+		kinds := make([]string, 0, len(cfg.Resources))
+		for _, cr := range cfg.Resources {
+			kinds = append(kinds, cr.Kind)
+		}
+		sort.Strings(kinds)
+		fmt.Printf("[CRDStage] kinds=%s added=%d edges\n", strings.Join(kinds, ","), g.EdgeCount()-before)
+		return err
+	}, nil
+}
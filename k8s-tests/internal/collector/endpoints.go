@@ -0,0 +1,151 @@
+package collector
+
+import (
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	discv1 "k8s.io/api/discovery/v1"
+)
+
+// Service traffic is modelled as
+//
+//	Service -endpoints-> EndpointSlice {addressType, ports, readyEndpoints}
+//	EndpointSlice -targets-> Pod {ready, serving, terminating}
+//
+// The slice is found through its kubernetes.io/service-name label, so the
+// chain also covers slices the EndpointSlice controller does not manage.
+// Services whose slices hold no ready endpoint get noReadyEndpoints=true
+// (see markServiceReadiness). ExternalName Services resolve through DNS,
+// have no slices and get neither property.
+
+// serviceSliceRelation derives the Service -endpoints-> EndpointSlice edge
+// from the slice, so it is owned as an inbound edge of the slice.
+func serviceSliceRelation() *relation {
+	rel := newRelation("service-endpointslices", "EndpointSlice",
+		[]EdgeKind{Endpoints}, []string{"Service"}, (*resolver).endpointSlices, deriveServiceSlice)
+	rel.inbound = true
+	return rel
+}
+
+func deriveServiceSlice(r *resolver, es *discv1.EndpointSlice) []Edge {
+	svc := es.Labels[discv1.LabelServiceName]
+	if svc == "" {
+		return nil
+	}
+	ready := 0
+	for _, ep := range es.Endpoints {
+		if endpointReady(ep) {
+			ready++
+		}
+	}
+	attrs := map[string]string{
+		"addressType":    string(es.AddressType),
+		"readyEndpoints": strconv.Itoa(ready),
+	}
+	if ports := slicePorts(es.Ports); ports != "" {
+		attrs["ports"] = ports
+	}
+	return []Edge{{
+		From:  r.node(es.Namespace, svc, "Service"),
+		To:    r.node(es.Namespace, es.Name, "EndpointSlice"),
+		Kind:  Endpoints,
+		Attrs: attrs,
+	}}
+}
+
+// slicePorts renders the slice's ports as "http=8774/TCP,9090/TCP".
+func slicePorts(ports []discv1.EndpointPort) string {
+	out := make([]string, 0, len(ports))
+	for _, p := range ports {
+		s := "*"
+		if p.Port != nil {
+			s = strconv.Itoa(int(*p.Port))
+		}
+		proto := "TCP"
+		if p.Protocol != nil {
+			proto = string(*p.Protocol)
+		}
+		s += "/" + proto
+		if p.Name != nil && *p.Name != "" {
+			s = *p.Name + "=" + s
+		}
+		out = append(out, s)
+	}
+	return strings.Join(out, ",")
+}
+
+// endpointReady follows the EndpointSlice API: an unset ready condition
+// means ready, and serving defaults to ready.
+func endpointReady(ep discv1.Endpoint) bool {
+	return ep.Conditions.Ready == nil || *ep.Conditions.Ready
+}
+
+func endpointAttrs(ep discv1.Endpoint) map[string]string {
+	ready := endpointReady(ep)
+	serving := ready
+	if ep.Conditions.Serving != nil {
+		serving = *ep.Conditions.Serving
+	}
+	terminating := ep.Conditions.Terminating != nil && *ep.Conditions.Terminating
+	return map[string]string{
+		"ready":       strconv.FormatBool(ready),
+		"serving":     strconv.FormatBool(serving),
+		"terminating": strconv.FormatBool(terminating),
+	}
+}
+
+func deriveEndpointSlicePods(r *resolver, es *discv1.EndpointSlice) []Edge {
+	from := r.node(es.Namespace, es.Name, "EndpointSlice")
+	var out []Edge
+	for _, ep := range es.Endpoints {
+		attrs := endpointAttrs(ep)
+		// 1) targetRef 있으면 그대로
+		if ep.TargetRef != nil && ep.TargetRef.Kind == "Pod" {
+			out = append(out, Edge{From: from, To: r.node(es.Namespace, ep.TargetRef.Name, "Pod"), Kind: Targets, Attrs: attrs})
+			continue
+		}
+		// 2) 없으면 IP 역-매핑
+		for _, addr := range ep.Addresses {
+			if pod := r.podByIP(addr); pod != nil {
+				out = append(out, Edge{From: from, To: r.node(pod.Namespace, pod.Name, "Pod"), Kind: Targets, Attrs: attrs})
+			}
+		}
+	}
+	return out
+}
+
+// markServiceReadiness sets readyEndpoints and noReadyEndpoints on the
+// Service nodes among uids from their -endpoints-> edges, and clears them
+// on ExternalName Services. Dual-stack
+// services have one slice family per address type; the best family counts.
+func markServiceReadiness(g *Graph, uids []string) {
+	for _, uid := range uids {
+		n, ok := g.Node(uid)
+		if !ok || n.Type != "Service" || n.Group != "" {
+			continue
+		}
+		if n.Props["serviceType"] == string(corev1.ServiceTypeExternalName) {
+			g.SetProps(uid, map[string]string{"readyEndpoints": "", "noReadyEndpoints": ""})
+			continue
+		}
+		byFamily := map[string]int{}
+		for _, e := range g.Incident(uid) {
+			if e.Kind != Endpoints || e.From != uid {
+				continue
+			}
+			n, _ := strconv.Atoi(e.Attrs["readyEndpoints"])
+			byFamily[e.Attrs["addressType"]] += n
+		}
+		ready := 0
+		for _, n := range byFamily {
+			if n > ready {
+				ready = n
+			}
+		}
+		g.SetProps(uid, map[string]string{
+			"readyEndpoints":   strconv.Itoa(ready),
+			"noReadyEndpoints": strconv.FormatBool(ready == 0),
+		})
+	}
+}
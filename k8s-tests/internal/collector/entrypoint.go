@@ -0,0 +1,209 @@
+package collector
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	discv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// openstack-helm pods declare what they wait on at startup in the env of
+// their kubernetes-entrypoint init container:
+//
+//	DEPENDENCY_SERVICE   "openstack:mariadb,openstack:keystone-api"
+//	DEPENDENCY_JOBS      "nova-db-sync,nova-rabbit-init"
+//	DEPENDENCY_DAEMONSET "openstack:libvirt-libvirt-default"
+//	DEPENDENCY_POD_JSON  [{"labels": {...}, "requireSameNode": true}]
+//
+// Each entry becomes Pod -waits-for-> Service|Job|DaemonSet|Pod
+// {container, env, ready}, where ready is what kubernetes-entrypoint would
+// see: a ready endpoint, a succeeded Job pod, a ready DaemonSet pod on the
+// pod's node, a ready matching pod. Pods still initializing that wait on an
+// unready dependency get stuckInInit=true and waitingFor (see
+// markInitWaits). ready is re-evaluated when the pod or the dependency
+// object changes, and on the events that decide it: EndpointSlices of a
+// Service (by their kubernetes.io/service-name label) and the pods of a
+// DaemonSet.
+
+// entrypointEnv maps the dependency variables to the kind they name.
+var entrypointEnv = map[string]string{
+	"DEPENDENCY_SERVICE":   "Service",
+	"DEPENDENCY_JOBS":      "Job",
+	"DEPENDENCY_DAEMONSET": "DaemonSet",
+}
+
+// podDependency is one DEPENDENCY_POD_JSON entry.
+type podDependency struct {
+	Labels          map[string]string `json:"labels"`
+	RequireSameNode bool              `json:"requireSameNode"`
+}
+
+func podWaitsRelation() *relation {
+	rel := newRelation("pod-waits", "Pod", []EdgeKind{WaitsFor},
+		[]string{"Service", "EndpointSlice", "Job", "DaemonSet", "Pod"}, (*resolver).pods, derivePodWaits)
+	rel.ends = func(kind string, target metav1.Object) []string {
+		ns := target.GetNamespace()
+		switch kind {
+		case "EndpointSlice":
+			if svc := target.GetLabels()[discv1.LabelServiceName]; svc != "" {
+				return []string{KeyOf("Service", ns, svc).ID()}
+			}
+			return nil
+		case "Pod":
+			out := []string{KeyOf("Pod", ns, target.GetName()).ID()}
+			if ref := metav1.GetControllerOf(target); ref != nil && ref.Kind == "DaemonSet" {
+				out = append(out, KeyOf("DaemonSet", ns, ref.Name).ID())
+			}
+			return out
+		}
+		return []string{KeyOf(kind, ns, target.GetName()).ID()}
+	}
+	return rel
+}
+
+func derivePodWaits(r *resolver, pod *corev1.Pod) []Edge {
+	from := r.node(pod.Namespace, pod.Name, "Pod")
+	var out []Edge
+	wait := func(to string, c *corev1.Container, env string, ready bool) {
+		out = append(out, Edge{From: from, To: to, Kind: WaitsFor, Attrs: map[string]string{
+			"container": c.Name,
+			"env":       env,
+			"ready":     strconv.FormatBool(ready),
+		}})
+	}
+	for i := range pod.Spec.InitContainers {
+		c := &pod.Spec.InitContainers[i]
+		for _, env := range c.Env {
+			if env.Value == "" {
+				continue
+			}
+			if env.Name == "DEPENDENCY_POD_JSON" {
+				var deps []podDependency
+				if err := json.Unmarshal([]byte(env.Value), &deps); err != nil {
+					continue
+				}
+				for _, d := range deps {
+					for _, p := range r.podsIn(pod.Namespace) {
+						if len(d.Labels) == 0 || !labels.SelectorFromSet(d.Labels).Matches(labels.Set(p.Labels)) {
+							continue
+						}
+						if d.RequireSameNode && p.Spec.NodeName != pod.Spec.NodeName {
+							continue
+						}
+						wait(r.node(p.Namespace, p.Name, "Pod"), c, env.Name, podReady(p))
+					}
+				}
+				continue
+			}
+			kind, ok := entrypointEnv[env.Name]
+			if !ok {
+				continue
+			}
+			for _, ref := range strings.Split(env.Value, ",") {
+				ns, name := pod.Namespace, strings.TrimSpace(ref)
+				if i := strings.Index(name, ":"); i >= 0 {
+					ns, name = name[:i], name[i+1:]
+				}
+				if name == "" {
+					continue
+				}
+				wait(r.node(ns, name, kind), c, env.Name, r.dependencyReady(kind, ns, name, pod))
+			}
+		}
+	}
+	return out
+}
+
+// dependencyReady reports whether kubernetes-entrypoint in pod would
+// consider the named dependency resolved.
+func (r *resolver) dependencyReady(kind, ns, name string, pod *corev1.Pod) bool {
+	switch kind {
+	case "Service":
+		return r.serviceHasReadyEndpoint(ns, name)
+	case "Job":
+		for _, j := range r.jobs() {
+			if j.Namespace == ns && j.Name == name {
+				return j.Status.Succeeded > 0
+			}
+		}
+	case "DaemonSet":
+		for _, p := range r.podsIn(ns) {
+			ref := metav1.GetControllerOf(p)
+			if ref == nil || ref.Kind != "DaemonSet" || ref.Name != name {
+				continue
+			}
+			if (pod.Spec.NodeName == "" || p.Spec.NodeName == pod.Spec.NodeName) && podReady(p) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// serviceHasReadyEndpoint reports whether any EndpointSlice of the Service
+// holds a ready endpoint.
+func (r *resolver) serviceHasReadyEndpoint(ns, name string) bool {
+	return cached(r, "EndpointSlice@ready", func() map[string]bool {
+		m := map[string]bool{}
+		for _, es := range r.endpointSlices() {
+			svc := es.Labels[discv1.LabelServiceName]
+			for _, ep := range es.Endpoints {
+				if svc != "" && endpointReady(ep) {
+					m[es.Namespace+"/"+svc] = true
+				}
+			}
+		}
+		return m
+	})[ns+"/"+name]
+}
+
+func podReady(p *corev1.Pod) bool {
+	for _, c := range p.Status.Conditions {
+		if c.Type == corev1.PodReady {
+			return c.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// markInitWaits sets stuckInInit and waitingFor on the Pods among uids
+// with -waits-for-> edges. A pod is stuck when its Initialized condition
+// is False and at least one dependency is unready; waitingFor lists those
+// dependencies either way.
+func markInitWaits(g *Graph, uids []string) {
+	for _, uid := range uids {
+		n, ok := g.Node(uid)
+		if !ok || n.Type != "Pod" || n.Group != "" {
+			continue
+		}
+		waits, unready := false, map[string]bool{}
+		for _, e := range g.Incident(uid) {
+			if e.Kind != WaitsFor || e.From != uid {
+				continue
+			}
+			waits = true
+			if e.Attrs["ready"] != "true" {
+				unready[e.To] = true
+			}
+		}
+		if !waits && n.Props["stuckInInit"] == "" && n.Props["waitingFor"] == "" {
+			continue
+		}
+		props := map[string]string{"stuckInInit": "", "waitingFor": ""}
+		if waits {
+			waiting := make([]string, 0, len(unready))
+			for to := range unready {
+				waiting = append(waiting, to)
+			}
+			sort.Strings(waiting)
+			props["waitingFor"] = strings.Join(waiting, ",")
+			props["stuckInInit"] = strconv.FormatBool(len(waiting) > 0 && n.Props["condition.Initialized"] == "False")
+		}
+		g.SetProps(uid, props)
+	}
+}
@@ -0,0 +1,133 @@
+package collector
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+// ListError reports a kind the client could not list, e.g. because RBAC
+// forbids it or the apiserver timed out.
+type ListError struct {
+	Kind string
+	Err  error
+}
+
+func (e *ListError) Error() string { return fmt.Sprintf("list %s: %v", e.Kind, e.Err) }
+func (e *ListError) Unwrap() error { return e.Err }
+
+// DegradedError marks a stage failure that leaves the graph degraded without
+// aborting the run under AbortOnError: the stage only adds edges from an
+// optional source outside the cluster, such as the Keystone catalog.
+type DegradedError struct {
+	Err error
+}
+
+func (e *DegradedError) Error() string { return e.Err.Error() }
+func (e *DegradedError) Unwrap() error { return e.Err }
+
+// FailurePolicy decides what Collector.Run does when a stage fails.
+type FailurePolicy int
+
+const (
+	// AbortOnError stops at the first failing stage and keeps the previous graph.
+	AbortOnError FailurePolicy = iota
+	// ContinueOnError runs every stage and publishes the degraded graph.
+	ContinueOnError
+)
+
+// ParseFailurePolicy accepts "abort" or "continue".
+func ParseFailurePolicy(s string) (FailurePolicy, error) {
+	switch s {
+	case "abort":
+		return AbortOnError, nil
+	case "continue":
+		return ContinueOnError, nil
+	}
+	return 0, fmt.Errorf("unknown failure policy %q (want abort or continue)", s)
+}
+
+// StageFailure records one stage that returned an error.
+type StageFailure struct {
+	Stage string
+	Kinds []string // kinds that failed to list; empty if the error was not a ListError
+	Err   error
+}
+
+// RunResult is the outcome of Collector.Run.
+type RunResult struct {
+	Graph    *Graph
+	Failures []StageFailure
+}
+
+// Degraded reports whether any stage failed, i.e. the graph may be missing
+// nodes and edges.
+func (r *RunResult) Degraded() bool { return len(r.Failures) > 0 }
+
+// FailedKinds returns every kind that failed to list, without duplicates.
+func (r *RunResult) FailedKinds() []string {
+	seen := make(map[string]bool)
+	var out []string
+	for _, f := range r.Failures {
+		for _, k := range f.Kinds {
+			if !seen[k] {
+				seen[k] = true
+				out = append(out, k)
+			}
+		}
+	}
+	return out
+}
+
+// Err joins the stage failures into one error, or returns nil.
+func (r *RunResult) Err() error {
+	errs := make([]error, 0, len(r.Failures))
+	for _, f := range r.Failures {
+		errs = append(errs, fmt.Errorf("%s: %w", f.Stage, f.Err))
+	}
+	return errors.Join(errs...)
+}
+
+func newStageFailure(st Stage, err error) StageFailure {
+	f := StageFailure{Stage: stageName(st), Err: err}
+	seen := make(map[string]bool)
+	walkErrors(err, func(e error) {
+		if le, ok := e.(*ListError); ok && !seen[le.Kind] {
+			seen[le.Kind] = true
+			f.Kinds = append(f.Kinds, le.Kind)
+		}
+	})
+	return f
+}
+
+// walkErrors calls fn for err and everything it wraps, including the
+// branches of joined errors.
+func walkErrors(err error, fn func(error)) {
+	if err == nil {
+		return
+	}
+	fn(err)
+	switch u := err.(type) {
+	case interface{ Unwrap() []error }:
+		for _, e := range u.Unwrap() {
+			walkErrors(e, fn)
+		}
+	case interface{ Unwrap() error }:
+		walkErrors(u.Unwrap(), fn)
+	}
+}
+
+// stageName returns the function name of st, e.g. "WorkloadStage". A stage
+// built by a constructor is a closure ("KeystoneCatalogStage.func1") and is
+// named after the constructor.
+func stageName(st Stage) string {
+	name := runtime.FuncForPC(reflect.ValueOf(st).Pointer()).Name()
+	name = name[strings.LastIndex(name, "/")+1:]
+	if i := strings.Index(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	name, _, _ = strings.Cut(name, ".func")
+	return name
+}
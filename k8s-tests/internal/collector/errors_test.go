@@ -0,0 +1,77 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/kaist2025/k8s-e2e-tests/internal/k8sclient"
+)
+
+// forbidPods behaves like a service account without RBAC on pods.
+type forbidPods struct {
+	*k8sclient.Fake
+}
+
+func (forbidPods) Pods(context.Context) ([]corev1.Pod, error) {
+	return nil, apierrors.NewForbidden(schema.GroupResource{Resource: "pods"}, "", errors.New("rbac"))
+}
+
+func TestRunFailurePolicy(t *testing.T) {
+	fake := loadFake(t, "pvc.yaml")
+	stages := []Stage{WorkloadStage, PVCStage}
+
+	t.Run("abort", func(t *testing.T) {
+		co := NewCollector(forbidPods{fake}, stages)
+		prev := co.Graph.AddNode("openstack", "previous", "Pod")
+		res, err := co.Run(context.Background())
+		if !apierrors.IsForbidden(err) {
+			t.Fatalf("err = %v, want forbidden", err)
+		}
+		if len(res.Failures) != 1 || res.Failures[0].Stage != "WorkloadStage" {
+			t.Fatalf("failures = %+v", res.Failures)
+		}
+		if _, ok := co.Graph.Snapshot().Nodes[prev]; !ok {
+			t.Fatal("aborted run replaced the previous graph")
+		}
+	})
+
+	t.Run("continue", func(t *testing.T) {
+		co := NewCollector(forbidPods{fake}, stages)
+		co.Policy = ContinueOnError
+		res, err := co.Run(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !res.Degraded() || !reflect.DeepEqual(res.FailedKinds(), []string{"Pod"}) {
+			t.Fatalf("degraded=%v kinds=%v", res.Degraded(), res.FailedKinds())
+		}
+		if _, ok := co.Graph.Lookup("PersistentVolume", "", "pvc-1234"); !ok {
+			t.Fatal("later stages did not run")
+		}
+	})
+}
+
+func TestRelinkKeepsEdgesWhenListFails(t *testing.T) {
+	fake := loadFake(t, "workload.yaml")
+	co := NewCollector(fake, []Stage{WorkloadStage})
+	if _, err := co.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	before := dumpGraph(co.Graph.Snapshot())
+
+	co.Client = forbidPods{fake}
+	svc := &corev1.Service{}
+	svc.Namespace, svc.Name = "openstack", "keystone-api"
+	svc.Spec.Selector = map[string]string{"application": "keystone", "component": "api"}
+	co.ApplyEvent("Service", "update", svc)
+
+	if got := dumpGraph(co.Graph.Snapshot()); got != before {
+		t.Fatalf("edges changed after failed relink:\n%s\nwant:\n%s", got, before)
+	}
+}
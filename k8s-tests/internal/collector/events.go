@@ -0,0 +1,130 @@
+package collector
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/tools/cache"
+)
+
+// Informer handlers run in one goroutine per informer, so events of
+// different kinds arrive concurrently. Enqueue hands them to a single worker
+// (Process) so that each relink sees the edges of the one before it; a
+// relink computed from older listings can no longer overwrite a newer one.
+
+// queuedEvent is the latest event seen for one object.
+type queuedEvent struct {
+	kind, event string
+	obj         interface{}
+}
+
+// eventQueue holds the events not yet applied, keyed by object, in order of
+// first arrival.
+type eventQueue struct {
+	mu      sync.Mutex
+	pending map[string]queuedEvent
+	order   []string
+	wake    chan struct{}
+}
+
+// wakeLocked returns the channel signalled when events are queued. The
+// caller holds q.mu.
+func (q *eventQueue) wakeLocked() chan struct{} {
+	if q.wake == nil {
+		q.wake = make(chan struct{}, 1)
+	}
+	return q.wake
+}
+
+func (q *eventQueue) push(key string, ev queuedEvent) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.pending == nil {
+		q.pending = make(map[string]queuedEvent)
+	}
+	if _, ok := q.pending[key]; !ok {
+		q.order = append(q.order, key)
+	}
+	q.pending[key] = ev
+	select {
+	case q.wakeLocked() <- struct{}{}:
+	default:
+	}
+}
+
+// take removes and returns every queued event.
+func (q *eventQueue) take() ([]queuedEvent, chan struct{}) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	batch := make([]queuedEvent, 0, len(q.order))
+	for _, key := range q.order {
+		batch = append(batch, q.pending[key])
+	}
+	q.pending, q.order = nil, nil
+	return batch, q.wakeLocked()
+}
+
+// Enqueue queues an informer event for Process. An event for an object that
+// is still queued replaces the earlier one: the node and its edges only
+// depend on the latest state, and a delete must win over the add before it.
+func (co *Collector) Enqueue(kind string, event string, obj interface{}) {
+	if tomb, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tomb.Obj
+	}
+	m, err := meta.Accessor(obj)
+	if err != nil {
+		log.Printf("invalid object type in event '%s' for kind '%s': %T\n", event, kind, obj)
+		return
+	}
+	key := KeyOf(kind, m.GetNamespace(), m.GetName()).ID()
+	co.queue.push(key, queuedEvent{kind: kind, event: event, obj: obj})
+}
+
+// Process applies queued events until ctx is done. Nothing is applied
+// before the first Run has built the graph, since the events of the initial
+// sync are already part of it. Each batch of queued events shares one
+// resolver, so a kind is listed once per batch rather than once per event
+// and relation.
+func (co *Collector) Process(ctx context.Context) {
+	select {
+	case <-co.readyCh():
+	case <-ctx.Done():
+		return
+	}
+	for {
+		batch, wake := co.queue.take()
+		if len(batch) == 0 {
+			select {
+			case <-wake:
+				continue
+			case <-ctx.Done():
+				return
+			}
+		}
+		co.mu.Lock()
+		var r *resolver
+		if co.Client != nil {
+			r = newResolver(ctx, co.Client, co.Graph)
+		}
+		for _, ev := range batch {
+			co.apply(r, ev.kind, ev.event, ev.obj)
+		}
+		co.mu.Unlock()
+	}
+}
+
+// readyCh returns the channel closed when the first Run completes.
+func (co *Collector) readyCh() chan struct{} {
+	co.mu.Lock()
+	defer co.mu.Unlock()
+	return co.readyLocked()
+}
+
+func (co *Collector) readyLocked() chan struct{} {
+	if co.ready == nil {
+		co.ready = make(chan struct{})
+	}
+	return co.ready
+}
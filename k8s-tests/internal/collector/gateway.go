@@ -0,0 +1,332 @@
+package collector
+
+import (
+	"strconv"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Gateway API edges:
+//
+//	GatewayClass -served-by-> Pod {controller}
+//	Gateway -uses-> GatewayClass
+//	Gateway -reads-> Secret {listener} (listener TLS certificateRefs)
+//	Gateway -routes-> HTTPRoute|GRPCRoute {listener, port}
+//	HTTPRoute|GRPCRoute -routes-> Service {host, path, port, weight}
+//
+// References into another namespace (certificates, backends) are valid only
+// when a ReferenceGrant there allows them. Those edges name the grant in
+// referenceGrant, or carry unresolved=RefNotPermitted like the route's
+// ResolvedRefs condition; a ReferenceGrant event re-derives the Gateways and
+// routes that may refer into its namespace (relinkGrantReferrers). The objects are read as unstructured, so the
+// collector does not depend on the Gateway API Go module; clusters without
+// the CRDs simply have none.
+
+const gatewayGroup = "gateway.networking.k8s.io"
+
+// GatewayAPIKinds are the Gateway API kinds and versions GatewayStage reads.
+var GatewayAPIKinds = map[string]schema.GroupVersionKind{
+	"GatewayClass":   {Group: gatewayGroup, Version: "v1", Kind: "GatewayClass"},
+	"Gateway":        {Group: gatewayGroup, Version: "v1", Kind: "Gateway"},
+	"HTTPRoute":      {Group: gatewayGroup, Version: "v1", Kind: "HTTPRoute"},
+	"GRPCRoute":      {Group: gatewayGroup, Version: "v1", Kind: "GRPCRoute"},
+	"ReferenceGrant": {Group: gatewayGroup, Version: "v1beta1", Kind: "ReferenceGrant"},
+}
+
+// The parts of the Gateway API specs the collector reads. Pointer fields
+// are the ones whose defaults depend on where the reference is made.
+type (
+	gatewayClassSpec struct {
+		ControllerName string `json:"controllerName"`
+	}
+	gatewaySpec struct {
+		GatewayClassName string            `json:"gatewayClassName"`
+		Listeners        []gatewayListener `json:"listeners"`
+	}
+	gatewayListener struct {
+		Name string `json:"name"`
+		TLS  *struct {
+			CertificateRefs []gatewayRef `json:"certificateRefs"`
+		} `json:"tls"`
+	}
+	gatewayRef struct {
+		Group       *string `json:"group"`
+		Kind        *string `json:"kind"`
+		Namespace   *string `json:"namespace"`
+		Name        string  `json:"name"`
+		SectionName *string `json:"sectionName"`
+		Port        *int32  `json:"port"`
+		Weight      *int32  `json:"weight"`
+	}
+	routeSpec struct {
+		ParentRefs []gatewayRef `json:"parentRefs"`
+		Hostnames  []string     `json:"hostnames"`
+		Rules      []struct {
+			Matches     []map[string]interface{} `json:"matches"`
+			BackendRefs []gatewayRef             `json:"backendRefs"`
+		} `json:"rules"`
+	}
+	referenceGrantSpec struct {
+		From []struct {
+			Group     string `json:"group"`
+			Kind      string `json:"kind"`
+			Namespace string `json:"namespace"`
+		} `json:"from"`
+		To []struct {
+			Group string  `json:"group"`
+			Kind  string  `json:"kind"`
+			Name  *string `json:"name"`
+		} `json:"to"`
+	}
+)
+
+// decodeSpec converts u's spec into into; a malformed spec decodes as empty.
+func decodeSpec(u *unstructured.Unstructured, into interface{}) {
+	spec, _, _ := unstructured.NestedMap(u.Object, "spec")
+	_ = runtime.DefaultUnstructuredConverter.FromUnstructured(spec, into)
+}
+
+// key resolves ref made from namespace ns, with the group and kind defaults
+// of the field it appears in.
+func (ref gatewayRef) key(ns, group, kind string) NodeKey {
+	if ref.Group != nil {
+		group = *ref.Group
+	}
+	if ref.Kind != nil {
+		kind = *ref.Kind
+	}
+	if ref.Namespace != nil && *ref.Namespace != "" {
+		ns = *ref.Namespace
+	}
+	if isClusterScoped(kind) {
+		ns = ""
+	}
+	return NodeKey{Group: group, Kind: kind, Namespace: ns, Name: ref.Name}
+}
+
+func (r *resolver) gatewayObjects(kind string) []unstructured.Unstructured {
+	return r.customObjects(kind, GatewayAPIKinds[kind])
+}
+
+// referenceGrant returns the name of a ReferenceGrant in to's namespace that
+// lets objects of fromKind in fromNS refer to to, or "".
+func (r *resolver) referenceGrant(fromKind, fromNS string, to NodeKey) string {
+	for _, u := range r.gatewayObjects("ReferenceGrant") {
+		if u.GetNamespace() != to.Namespace {
+			continue
+		}
+		var spec referenceGrantSpec
+		decodeSpec(&u, &spec)
+		from := false
+		for _, f := range spec.From {
+			from = from || (f.Group == gatewayGroup && f.Kind == fromKind && f.Namespace == fromNS)
+		}
+		if !from {
+			continue
+		}
+		for _, t := range spec.To {
+			if t.Group == to.Group && t.Kind == to.Kind && (t.Name == nil || *t.Name == "" || *t.Name == to.Name) {
+				return u.GetName()
+			}
+		}
+	}
+	return ""
+}
+
+// crossNamespace adds referenceGrant or unresolved to attrs when a
+// reference from obj leaves its namespace.
+func (r *resolver) crossNamespace(kind string, obj metav1.Object, to NodeKey, attrs map[string]string) {
+	if to.Namespace == "" || to.Namespace == obj.GetNamespace() {
+		return
+	}
+	if grant := r.referenceGrant(kind, obj.GetNamespace(), to); grant != "" {
+		attrs["referenceGrant"] = to.Namespace + "/" + grant
+	} else {
+		attrs["unresolved"] = "RefNotPermitted"
+	}
+}
+
+// grantReferrers are the kinds whose references ReferenceGrants permit.
+var grantReferrers = []string{"Gateway", "HTTPRoute", "GRPCRoute"}
+
+// relinkGrantReferrers re-derives the grantReferrers outside ns, the
+// namespace of a ReferenceGrant that changed, so that the referenceGrant and
+// unresolved attributes of their edges into ns follow the grant.
+func (r *resolver) relinkGrantReferrers(ns string) error {
+	for _, kind := range grantReferrers {
+		objs := r.gatewayObjects(kind)
+		if err := r.err(); err != nil {
+			return err
+		}
+		for i := range objs {
+			if objs[i].GetNamespace() == ns {
+				continue
+			}
+			if err := r.relink(kind, &objs[i], false, nil); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func deriveGatewayClassPods(r *resolver, u *unstructured.Unstructured) []Edge {
+	var spec gatewayClassSpec
+	decodeSpec(u, &spec)
+	from := r.node("", u.GetName(), "GatewayClass")
+	var out []Edge
+	for _, pod := range r.controllerPods(spec.ControllerName) {
+		out = append(out, Edge{
+			From: from, To: r.node(pod.Namespace, pod.Name, "Pod"), Kind: ServedBy,
+			Attrs: map[string]string{"controller": spec.ControllerName},
+		})
+	}
+	return out
+}
+
+func deriveGatewayRefs(r *resolver, u *unstructured.Unstructured) []Edge {
+	var spec gatewaySpec
+	decodeSpec(u, &spec)
+	from := r.node(u.GetNamespace(), u.GetName(), "Gateway")
+	var out []Edge
+	if spec.GatewayClassName != "" {
+		out = append(out, Edge{From: from, To: r.node("", spec.GatewayClassName, "GatewayClass"), Kind: Uses})
+	}
+	for _, l := range spec.Listeners {
+		if l.TLS == nil {
+			continue
+		}
+		for _, ref := range l.TLS.CertificateRefs {
+			key := ref.key(u.GetNamespace(), "", "Secret")
+			attrs := map[string]string{"listener": l.Name}
+			r.crossNamespace("Gateway", u, key, attrs)
+			out = append(out, Edge{From: from, To: r.g.AddKey(key, ""), Kind: Reads, Attrs: attrs})
+		}
+	}
+	return out
+}
+
+// deriveRouteParents links the Gateways (or other parents) a route attaches
+// to. The edges end at the route.
+func deriveRouteParents(kind string) func(r *resolver, u *unstructured.Unstructured) []Edge {
+	return func(r *resolver, u *unstructured.Unstructured) []Edge {
+		var spec routeSpec
+		decodeSpec(u, &spec)
+		to := r.node(u.GetNamespace(), u.GetName(), kind)
+		var out []Edge
+		for _, ref := range spec.ParentRefs {
+			attrs := map[string]string{"listener": "*"}
+			if ref.SectionName != nil && *ref.SectionName != "" {
+				attrs["listener"] = *ref.SectionName
+			}
+			if ref.Port != nil {
+				attrs["port"] = strconv.Itoa(int(*ref.Port))
+			}
+			key := ref.key(u.GetNamespace(), gatewayGroup, "Gateway")
+			out = append(out, Edge{From: r.g.AddKey(key, ""), To: to, Kind: Routes, Attrs: attrs})
+		}
+		return out
+	}
+}
+
+// deriveRouteBackends links a route to its backends, one edge per rule
+// match: HTTP path matches give path (default "/"), GRPC method matches
+// "/service/method" with "*" for unset parts.
+func deriveRouteBackends(kind string) func(r *resolver, u *unstructured.Unstructured) []Edge {
+	return func(r *resolver, u *unstructured.Unstructured) []Edge {
+		var spec routeSpec
+		decodeSpec(u, &spec)
+		from := r.node(u.GetNamespace(), u.GetName(), kind)
+		host := "*"
+		if len(spec.Hostnames) > 0 {
+			host = strings.Join(spec.Hostnames, ",")
+		}
+		var out []Edge
+		for _, rule := range spec.Rules {
+			paths := routePaths(kind, rule.Matches)
+			for _, ref := range rule.BackendRefs {
+				key := ref.key(u.GetNamespace(), "", "Service")
+				to := r.g.AddKey(key, "")
+				for _, p := range paths {
+					attrs := map[string]string{"host": host, "path": p[0]}
+					if p[1] != "" {
+						attrs["pathType"] = p[1]
+					}
+					if ref.Port != nil {
+						attrs["port"] = strconv.Itoa(int(*ref.Port))
+					}
+					weight := int32(1)
+					if ref.Weight != nil {
+						weight = *ref.Weight
+					}
+					attrs["weight"] = strconv.Itoa(int(weight))
+					r.crossNamespace(kind, u, key, attrs)
+					out = append(out, Edge{From: from, To: to, Kind: Routes, Attrs: attrs})
+				}
+			}
+		}
+		return out
+	}
+}
+
+// routePaths returns the {path, pathType} pairs of a rule's matches.
+func routePaths(kind string, matches []map[string]interface{}) [][2]string {
+	if kind == "GRPCRoute" {
+		if len(matches) == 0 {
+			return [][2]string{{"*", ""}}
+		}
+		var out [][2]string
+		for _, m := range matches {
+			svc, _, _ := unstructured.NestedString(m, "method", "service")
+			method, _, _ := unstructured.NestedString(m, "method", "method")
+			if svc == "" {
+				svc = "*"
+			}
+			if method == "" {
+				method = "*"
+			}
+			out = append(out, [2]string{"/" + svc + "/" + method, ""})
+		}
+		return out
+	}
+	if len(matches) == 0 {
+		return [][2]string{{"/", "PathPrefix"}}
+	}
+	var out [][2]string
+	for _, m := range matches {
+		value, ok, _ := unstructured.NestedString(m, "path", "value")
+		if !ok {
+			value = "/"
+		}
+		typ, ok, _ := unstructured.NestedString(m, "path", "type")
+		if !ok {
+			typ = "PathPrefix"
+		}
+		out = append(out, [2]string{value, typ})
+	}
+	return out
+}
+
+func gatewayRelation(name, kind string, edges []EdgeKind, targets []string,
+	derive func(r *resolver, u *unstructured.Unstructured) []Edge) *relation {
+	return newRelation(name, kind, edges, targets,
+		func(r *resolver) []unstructured.Unstructured { return r.gatewayObjects(kind) }, derive)
+}
+
+var (
+	gatewayClassPods  = gatewayRelation("gatewayclass-pods", "GatewayClass", []EdgeKind{ServedBy}, []string{"Pod"}, deriveGatewayClassPods)
+	gatewayRefs       = gatewayRelation("gateway-refs", "Gateway", []EdgeKind{Uses, Reads}, []string{"GatewayClass", "Secret"}, deriveGatewayRefs)
+	httpRouteParents  = inboundRelation(gatewayRelation("httproute-parents", "HTTPRoute", []EdgeKind{Routes}, []string{"Gateway"}, deriveRouteParents("HTTPRoute")))
+	httpRouteBackends = gatewayRelation("httproute-backends", "HTTPRoute", []EdgeKind{Routes}, []string{"Service"}, deriveRouteBackends("HTTPRoute"))
+	grpcRouteParents  = inboundRelation(gatewayRelation("grpcroute-parents", "GRPCRoute", []EdgeKind{Routes}, []string{"Gateway"}, deriveRouteParents("GRPCRoute")))
+	grpcRouteBackends = gatewayRelation("grpcroute-backends", "GRPCRoute", []EdgeKind{Routes}, []string{"Service"}, deriveRouteBackends("GRPCRoute"))
+)
+
+func inboundRelation(rel *relation) *relation {
+	rel.inbound = true
+	return rel
+}
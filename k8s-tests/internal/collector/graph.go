@@ -2,7 +2,12 @@ package collector
 
 import (
 	"fmt"
+	"strings"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
 )
 
 type EdgeKind string
@@ -17,58 +22,428 @@ const (
 	Uses    EdgeKind = "uses"
 	Allow   EdgeKind = "allow"
 	Targets EdgeKind = "targets"
+	Scales  EdgeKind = "scales"
+	Protects EdgeKind = "protects"
+	RunsOn  EdgeKind = "runs-on"
+	LocatedIn EdgeKind = "located-in"
+	Selects   EdgeKind = "selects"
+	AdmitsFrom EdgeKind = "admits-from"
+	AdmitsTo  EdgeKind = "admits-to"
+	Subject   EdgeKind = "subject"
+	Grants    EdgeKind = "grants"
+	Permits   EdgeKind = "permits"
+	Attaches  EdgeKind = "attaches"
+	Endpoints EdgeKind = "endpoints"
+	ServedBy  EdgeKind = "served-by"
+	AttachedTo EdgeKind = "attached-to"
+	PartOf    EdgeKind = "part-of"
+	Implements EdgeKind = "implements"
+	Exposes   EdgeKind = "exposes"
+	DependsOn EdgeKind = "depends-on"
+	WaitsFor  EdgeKind = "waits-for"
 )
 
 type Node struct {
-	UID   string
-	Label string // 원본 resource 이름
-	Type  string // Deployment, Pod, Service, ...
-	NS    string // namespace
+	UID       string            // NodeKey.ID() — graph 내부 식별자
+	Label     string            // 원본 resource 이름
+	Type      string            // Deployment, Pod, Service, ...
+	Group     string            // API group ("" = core)
+	NS        string            // namespace
+	ObjectUID types.UID         // metadata.uid (참조로만 생성된 노드는 비어 있음)
+	Props     map[string]string // labels, phase, conditions, ... (objectProps 참고)
+}
+
+// Key returns the identity the node was created with.
+func (n Node) Key() NodeKey {
+	return NodeKey{Group: n.Group, Kind: n.Type, Namespace: n.NS, Name: n.Label}
 }
 
 type Edge struct {
-	From, To string   // UID
-	Kind    EdgeKind // relation
+	From, To string            // Node.UID
+	Kind     EdgeKind          // relation
+	Attrs    map[string]string // host, path, port, mountPath, key, policy, count, ...
 }
 
+// edgeIdentity lists, per edge kind, the attributes that tell parallel edges
+// between the same two nodes apart. Attributes not listed here (e.g. call
+// counts) are payload and are overwritten when the edge is added again.
+var edgeIdentity = map[EdgeKind][]string{
+	Routes:     {"host", "path", "port", "listener"},
+	Mounts:     {"container", "mountPath", "key"},
+	Reads:      {"container", "key", "env", "volume", "imagePullSecret", "listener", "hosts"},
+	AdmitsFrom: {"rule", "podSelector"},
+	AdmitsTo:   {"rule", "podSelector"},
+	Permits:    {"verbs", "resourceNames"},
+	Exposes:    {"interface", "region"},
+	DependsOn:  {"protocol", "port", "config", "key"},
+	WaitsFor:   {"container", "env"},
+}
+
+// derivedProps are node properties computed from the graph rather than
+// read from the object (see SetProps). Replacing a node's properties with
+// AddObject keeps them.
+var derivedProps = []string{"readyEndpoints", "noReadyEndpoints", "stuckInInit", "waitingFor"}
+
+// Graph is the mutable resource graph. It is safe for concurrent use:
+// informer handlers mutate it while exporters read point-in-time copies
+// obtained from Snapshot.
 type Graph struct {
-	Nodes   map[string]Node       // UID -> Node
-	Edges   map[string]Edge       // edgeID -> Edge
-	EdgeMap map[string]map[string]struct{} // UID -> set of edgeIDs
+	mu          sync.RWMutex
+	nodes       map[string]Node                // UID -> Node
+	edges       map[string]Edge                // edgeID -> Edge
+	edgeMap     map[string]map[string]struct{} // UID -> set of edgeIDs
+	byObjectUID map[types.UID]string           // metadata.uid -> UID
+
+	// shared이면 현재 map들을 snapshot(또는 Replace 원본)과 공유 중이므로
+	// 다음 변경 전에 복제(copy-on-write)한다. snap은 변경 전까지 재사용.
+	shared bool
+	snap   *Snapshot
+}
+
+func NewGraph() *Graph {
+	g := &Graph{}
+	g.init()
+	return g
 }
 
-func edgeID(from, to string, kind EdgeKind) string {
-	return fmt.Sprintf("%s->%s:%s", from, to, kind)
+func (g *Graph) init() {
+	if g.nodes == nil {
+		g.nodes = make(map[string]Node)
+		g.edges = make(map[string]Edge)
+		g.edgeMap = make(map[string]map[string]struct{})
+		g.byObjectUID = make(map[types.UID]string)
+	}
 }
 
-func (g *Graph) AddNode(ns, name, typ string) string {
-	uid := safeID(ns, name)
-	if _, exists := g.Nodes[uid]; !exists {
-		g.Nodes[uid] = Node{UID: uid, Label: name, Type: typ, NS: ns}
+// writable prepares the maps for mutation. Callers must hold g.mu.
+func (g *Graph) writable() {
+	g.init()
+	g.snap = nil
+	if !g.shared {
+		return
+	}
+	nodes := make(map[string]Node, len(g.nodes))
+	for k, v := range g.nodes {
+		nodes[k] = v
+	}
+	edges := make(map[string]Edge, len(g.edges))
+	for k, v := range g.edges {
+		edges[k] = v
+	}
+	edgeMap := make(map[string]map[string]struct{}, len(g.edgeMap))
+	for uid, set := range g.edgeMap {
+		cp := make(map[string]struct{}, len(set))
+		for id := range set {
+			cp[id] = struct{}{}
+		}
+		edgeMap[uid] = cp
+	}
+	byObjectUID := make(map[types.UID]string, len(g.byObjectUID))
+	for k, v := range g.byObjectUID {
+		byObjectUID[k] = v
+	}
+	g.nodes, g.edges, g.edgeMap, g.byObjectUID = nodes, edges, edgeMap, byObjectUID
+	g.shared = false
+}
+
+// Snapshot returns an immutable view of the graph as of now. Taking a
+// snapshot is O(1); the first mutation afterwards pays for the copy.
+func (g *Graph) Snapshot() *Snapshot {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.init()
+	if g.snap == nil {
+		g.snap = &Snapshot{
+			Nodes:       g.nodes,
+			Edges:       g.edges,
+			edgeMap:     g.edgeMap,
+			byObjectUID: g.byObjectUID,
+		}
+		g.shared = true
+	}
+	return g.snap
+}
+
+// NodeCount and EdgeCount report the current graph size.
+func (g *Graph) NodeCount() int {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return len(g.nodes)
+}
+
+func (g *Graph) EdgeCount() int {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return len(g.edges)
+}
+
+// Replace swaps in the contents of other, e.g. a freshly collected graph.
+func (g *Graph) Replace(other *Graph) {
+	other.mu.Lock()
+	other.init()
+	nodes, edges, edgeMap, byObjectUID := other.nodes, other.edges, other.edgeMap, other.byObjectUID
+	other.shared = true
+	other.mu.Unlock()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.nodes, g.edges, g.edgeMap, g.byObjectUID = nodes, edges, edgeMap, byObjectUID
+	// other(또는 그 snapshot)가 같은 map을 보고 있을 수 있으므로 공유로 표시
+	g.shared = true
+	g.snap = nil
+}
+
+func edgeID(from, to string, kind EdgeKind, attrs map[string]string) string {
+	id := fmt.Sprintf("%s->%s:%s", from, to, kind)
+	var parts []string
+	for _, k := range edgeIdentity[kind] {
+		if v, ok := attrs[k]; ok {
+			parts = append(parts, k+"="+v)
+		}
+	}
+	if len(parts) > 0 {
+		id += "[" + strings.Join(parts, ",") + "]"
+	}
+	return id
+}
+
+// ID returns the key the edge is stored under in Graph.Edges.
+func (e Edge) ID() string {
+	return edgeID(e.From, e.To, e.Kind, e.Attrs)
+}
+
+// AddNode adds a node referenced by kind/namespace/name, e.g. the target of a
+// selector or a volume reference, and returns its UID.
+func (g *Graph) AddNode(ns, name, kind string) string {
+	return g.AddKey(KeyOf(kind, ns, name), "")
+}
+
+// AddKey adds the node for key if it is missing and records objUID when given.
+func (g *Graph) AddKey(key NodeKey, objUID types.UID) string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.addKey(key, objUID, nil)
+}
+
+// addKey does the work of AddKey and, when props is non-nil, replaces the
+// node's properties. Callers must hold g.mu.
+func (g *Graph) addKey(key NodeKey, objUID types.UID, props map[string]string) string {
+	uid := key.ID()
+	n, exists := g.nodes[uid]
+	if exists && (objUID == "" || n.ObjectUID == objUID) && props == nil {
+		return uid
+	}
+	g.writable()
+	if !exists {
+		n = Node{UID: uid, Label: key.Name, Type: key.Kind, Group: key.Group, NS: key.Namespace}
+	}
+	if objUID != "" && n.ObjectUID != objUID {
+		if n.ObjectUID != "" {
+			delete(g.byObjectUID, n.ObjectUID)
+		}
+		n.ObjectUID = objUID
+		g.byObjectUID[objUID] = uid
+	}
+	if props != nil {
+		for _, k := range derivedProps {
+			if v, ok := n.Props[k]; ok {
+				if _, set := props[k]; !set {
+					props[k] = v
+				}
+			}
+		}
+		n.Props = props
 	}
+	g.nodes[uid] = n
 	return uid
 }
 
+// SetProps merges derived properties into the node uid; an empty value
+// removes the property. Missing nodes and unchanged properties are left
+// alone.
+func (g *Graph) SetProps(uid string, props map[string]string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	n, ok := g.nodes[uid]
+	if !ok {
+		return
+	}
+	changed := false
+	for k, v := range props {
+		if cur, set := n.Props[k]; cur != v || (v == "" && set) {
+			changed = true
+			break
+		}
+	}
+	if !changed {
+		return
+	}
+	g.writable()
+	merged := make(map[string]string, len(n.Props)+len(props))
+	for k, v := range n.Props {
+		merged[k] = v
+	}
+	for k, v := range props {
+		if v == "" {
+			delete(merged, k)
+		} else {
+			merged[k] = v
+		}
+	}
+	n.Props = merged
+	g.nodes[uid] = n
+}
+
+// AddObject adds the node for a live object of the given kind, keyed by its
+// kind, namespace and name and indexed by its metadata.uid. The node's
+// properties are replaced with the ones extracted from obj.
+func (g *Graph) AddObject(kind string, obj interface{}) string {
+	m, err := meta.Accessor(obj)
+	if err != nil {
+		return ""
+	}
+	props := objectProps(obj)
+	if props == nil {
+		props = map[string]string{}
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.addKey(KeyOf(kind, m.GetNamespace(), m.GetName()), m.GetUID(), props)
+}
+
+// Lookup finds the node for kind/namespace/name.
+func (g *Graph) Lookup(kind, ns, name string) (Node, bool) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	n, ok := g.nodes[KeyOf(kind, ns, name).ID()]
+	return n, ok
+}
+
+// LookupUID finds the node backed by the object with the given metadata.uid.
+func (g *Graph) LookupUID(objUID types.UID) (Node, bool) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	uid, ok := g.byObjectUID[objUID]
+	if !ok {
+		return Node{}, false
+	}
+	n, ok := g.nodes[uid]
+	return n, ok
+}
+
 func (g *Graph) AddEdge(fromUID, toUID string, kind EdgeKind) {
-	if g.Edges == nil {
-		g.Edges = make(map[string]Edge)
+	g.AddEdgeAttrs(fromUID, toUID, kind, nil)
+}
+
+// AddEdgeAttrs adds an edge carrying attrs. Edges whose identity attributes
+// (see edgeIdentity) differ are kept side by side. attrs is stored as-is and
+// must not be modified afterwards.
+func (g *Graph) AddEdgeAttrs(fromUID, toUID string, kind EdgeKind, attrs map[string]string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.addEdge(Edge{From: fromUID, To: toUID, Kind: kind, Attrs: attrs})
+}
+
+// addEdge stores e. Callers must hold g.mu.
+func (g *Graph) addEdge(e Edge) {
+	g.writable()
+	id := e.ID()
+	g.edges[id] = e
+
+	for _, uid := range []string{e.From, e.To} {
+		if g.edgeMap[uid] == nil {
+			g.edgeMap[uid] = make(map[string]struct{})
+		}
+		g.edgeMap[uid][id] = struct{}{}
+	}
+}
+
+// removeEdge deletes the edge stored under id. Callers must hold g.mu.
+func (g *Graph) removeEdge(id string) {
+	e, ok := g.edges[id]
+	if !ok {
+		return
 	}
-	if g.EdgeMap == nil {
-		g.EdgeMap = make(map[string]map[string]struct{})
+	g.writable()
+	delete(g.edges, id)
+	for _, uid := range []string{e.From, e.To} {
+		delete(g.edgeMap[uid], id)
+		if len(g.edgeMap[uid]) == 0 {
+			delete(g.edgeMap, uid)
+		}
 	}
-	id := edgeID(fromUID, toUID, kind)
-	g.Edges[id] = Edge{From: fromUID, To: toUID, Kind: kind}
+}
 
-	for _, uid := range []string{fromUID, toUID} {
-		if g.EdgeMap[uid] == nil {
-			g.EdgeMap[uid] = make(map[string]struct{})
+// Relink atomically removes the edges selected by drop and adds add. Only
+// edges incident to the scope UIDs are considered for removal; a nil scope
+// considers every edge. It returns the nodes of the removed and added
+// edges.
+func (g *Graph) Relink(scope []string, drop func(Edge) bool, add []Edge) []string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.init()
+
+	var stale []string
+	if scope == nil {
+		for id, e := range g.edges {
+			if drop(e) {
+				stale = append(stale, id)
+			}
+		}
+	} else {
+		for _, uid := range scope {
+			for id := range g.edgeMap[uid] {
+				if drop(g.edges[id]) {
+					stale = append(stale, id)
+				}
+			}
+		}
+	}
+	seen := map[string]bool{}
+	var touched []string
+	touch := func(e Edge) {
+		for _, uid := range []string{e.From, e.To} {
+			if !seen[uid] {
+				seen[uid] = true
+				touched = append(touched, uid)
+			}
+		}
+	}
+	for _, id := range stale {
+		if e, ok := g.edges[id]; ok {
+			touch(e)
+			g.removeEdge(id)
 		}
-		g.EdgeMap[uid][id] = struct{}{}
 	}
+	for _, e := range add {
+		touch(e)
+		g.addEdge(e)
+	}
+	return touched
+}
+
+// Node returns the node stored under uid.
+func (g *Graph) Node(uid string) (Node, bool) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	n, ok := g.nodes[uid]
+	return n, ok
+}
+
+// Incident returns the edges that start or end at uid. Unlike
+// Snapshot().Incident it does not make the next mutation copy the graph.
+func (g *Graph) Incident(uid string) []Edge {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	out := make([]Edge, 0, len(g.edgeMap[uid]))
+	for id := range g.edgeMap[uid] {
+		out = append(out, g.edges[id])
+	}
+	return out
 }
 
 func (g *Graph) AddResource(kind string, obj *unstructured.Unstructured) {
-	_ = g.AddNode(obj.GetNamespace(), obj.GetName(), kind)
+	_ = g.AddObject(kind, obj)
 }
 
 func (g *Graph) UpdateResource(kind string, oldObj, newObj *unstructured.Unstructured) {
@@ -76,13 +451,24 @@ func (g *Graph) UpdateResource(kind string, oldObj, newObj *unstructured.Unstruc
 }
 
 func (g *Graph) DeleteResource(kind string, obj *unstructured.Unstructured) {
-	uid := safeID(obj.GetNamespace(), obj.GetName())
-	delete(g.Nodes, uid)
+	g.RemoveNode(KeyOf(kind, obj.GetNamespace(), obj.GetName()).ID())
+}
 
-	if edgeSet, ok := g.EdgeMap[uid]; ok {
-		for eid := range edgeSet {
-			delete(g.Edges, eid)
-		}
-		delete(g.EdgeMap, uid)
+// RemoveNode removes the node uid and its edges, e.g. a HelmRelease node
+// left without members.
+func (g *Graph) RemoveNode(uid string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	n, ok := g.nodes[uid]
+	if !ok {
+		return
+	}
+	g.writable()
+	if n.ObjectUID != "" {
+		delete(g.byObjectUID, n.ObjectUID)
+	}
+	delete(g.nodes, uid)
+	for eid := range g.edgeMap[uid] {
+		g.removeEdge(eid)
 	}
 }
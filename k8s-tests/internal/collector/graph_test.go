@@ -0,0 +1,155 @@
+package collector
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	discv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestNodeIdentityIncludesKind(t *testing.T) {
+	g := NewGraph()
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "kube-system", Name: "ovn-northd-fclq7", UID: "p1"}}
+	es := &discv1.EndpointSlice{ObjectMeta: metav1.ObjectMeta{Namespace: "kube-system", Name: "ovn-northd-fclq7", UID: "e1"}}
+
+	podUID := g.AddObject("Pod", pod)
+	esUID := g.AddObject("EndpointSlice", es)
+	if podUID == esUID {
+		t.Fatalf("Pod and EndpointSlice share UID %q", podUID)
+	}
+	if n, ok := g.Lookup("Pod", "kube-system", "ovn-northd-fclq7"); !ok || n.Type != "Pod" {
+		t.Fatalf("Lookup(Pod) = %+v, %v", n, ok)
+	}
+	if n, ok := g.LookupUID("e1"); !ok || n.UID != esUID || n.Group != "discovery.k8s.io" {
+		t.Fatalf("LookupUID(e1) = %+v, %v", n, ok)
+	}
+}
+
+// A reference names its group through apiVersion; the registry only fills
+// in references that leave it out.
+func TestRefKeyGroup(t *testing.T) {
+	for _, tc := range []struct {
+		apiVersion, kind, want string
+	}{
+		{"apps/v1", "Deployment", "apps"},
+		{"example.org/v1alpha1", "Deployment", "example.org"},
+		{"v1", "Node", ""},
+		{"", "Deployment", "apps"},
+	} {
+		if got := RefKey("ns", tc.apiVersion, tc.kind, "x").Group; got != tc.want {
+			t.Errorf("RefKey(%q, %q) group = %q, want %q", tc.apiVersion, tc.kind, got, tc.want)
+		}
+	}
+}
+
+func TestParallelEdgesKeptApartByIdentityAttrs(t *testing.T) {
+	g := NewGraph()
+	ing := g.AddNode("openstack", "nova", "Ingress")
+	svc := g.AddNode("openstack", "nova-api", "Service")
+	g.AddEdgeAttrs(ing, svc, Routes, map[string]string{"host": "nova", "path": "/", "port": "8774"})
+	g.AddEdgeAttrs(ing, svc, Routes, map[string]string{"host": "nova", "path": "/v2.1", "port": "8774"})
+
+	a := g.AddNode("", "nova", "Service")
+	b := g.AddNode("", "keystone", "Service")
+	g.AddEdgeAttrs(a, b, Calls, map[string]string{"count": "1"})
+	g.AddEdgeAttrs(a, b, Calls, map[string]string{"count": "7"})
+
+	snap := g.Snapshot()
+	if len(snap.Edges) != 3 {
+		t.Fatalf("edges = %d, want 3", len(snap.Edges))
+	}
+	for _, e := range snap.Edges {
+		if e.Kind == Calls && e.Attrs["count"] != "7" {
+			t.Errorf("calls count = %s, want 7", e.Attrs["count"])
+		}
+	}
+}
+
+func TestSnapshotIsPointInTime(t *testing.T) {
+	g := NewGraph()
+	a := g.AddNode("ns", "a", "Pod")
+	snap := g.Snapshot()
+
+	b := g.AddNode("ns", "b", "Pod")
+	g.AddEdge(a, b, Allow)
+	g.DeleteResource("Pod", toUnstructuredOrDie(t, &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "a"}}))
+
+	if len(snap.Nodes) != 1 || len(snap.Edges) != 0 {
+		t.Fatalf("snapshot changed: nodes=%d edges=%d", len(snap.Nodes), len(snap.Edges))
+	}
+	if _, ok := snap.Nodes[a]; !ok {
+		t.Fatalf("snapshot lost node %s", a)
+	}
+	if now := g.Snapshot(); len(now.Nodes) != 1 || len(now.Edges) != 0 {
+		t.Fatalf("current graph: nodes=%d edges=%d", len(now.Nodes), len(now.Edges))
+	}
+	if g.Snapshot() != g.Snapshot() {
+		t.Fatalf("unchanged graph should reuse its snapshot")
+	}
+}
+
+// go test -race 로 실행해야 의미가 있음
+func TestConcurrentEventsAndSnapshots(t *testing.T) {
+	co := NewCollector(nil, nil)
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+					Namespace: "ns",
+					Name:      fmt.Sprintf("pod-%d-%d", w, i%20),
+					Labels:    map[string]string{"i": fmt.Sprint(i)},
+				}}
+				switch i % 3 {
+				case 0:
+					co.ApplyEvent("Pod", "add", pod)
+				case 1:
+					co.ApplyEvent("Pod", "update", pod)
+				default:
+					co.ApplyEvent("Pod", "delete", pod)
+				}
+			}
+		}(w)
+	}
+
+	readers := sync.WaitGroup{}
+	readers.Add(1)
+	go func() {
+		defer readers.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			snap := co.Graph.Snapshot()
+			for _, n := range snap.Nodes {
+				_ = n.Props["label.i"]
+			}
+			for _, e := range snap.Edges {
+				_ = snap.Nodes[e.From]
+			}
+		}
+	}()
+
+	wg.Wait()
+	close(stop)
+	readers.Wait()
+}
+
+func toUnstructuredOrDie(t *testing.T, obj interface{}) *unstructured.Unstructured {
+	t.Helper()
+	u, err := toUnstructured(obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u
+}
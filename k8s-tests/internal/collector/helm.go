@@ -0,0 +1,252 @@
+package collector
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Helm releases are modelled as HelmRelease nodes keyed by release
+// namespace and name:
+//
+//	<object> -part-of-> HelmRelease
+//	Secret sh.helm.release.v1.<name>.v<rev> -part-of-> HelmRelease {revision}
+//
+// Membership comes from the meta.helm.sh/release-name and
+// meta.helm.sh/release-namespace annotations Helm 3 puts on every object it
+// manages. The release node carries chart, chartVersion, appVersion,
+// revision and status from the newest release Secret (see
+// markHelmReleases), and is removed once no member or Secret is left
+// (helm uninstall). Only the chart metadata and release info are read from
+// the Secret payload, once per Secret version (markReleaseSecret); values
+// and manifests are never kept.
+
+const (
+	helmReleaseName      = "meta.helm.sh/release-name"
+	helmReleaseNamespace = "meta.helm.sh/release-namespace"
+	helmReleaseType      = corev1.SecretType("helm.sh/release.v1")
+)
+
+// helmProps are the node properties markHelmReleases owns.
+var helmProps = []string{"chart", "chartVersion", "appVersion", "revision", "status"}
+
+// releasePrefix prefixes the payload properties markReleaseSecret puts on
+// release Secret nodes: chart, chartVersion, appVersion and status.
+const releasePrefix = "release."
+
+// partOfRelations derive the part-of edge of every collected kind, one
+// relation per owner relation so both cover the same objects.
+var partOfRelations = partOfAll(ownerRelations)
+
+func partOfAll(owners []*relation) []*relation {
+	out := make([]*relation, 0, len(owners))
+	for _, o := range owners {
+		out = append(out, partOfRelation(o))
+	}
+	return out
+}
+
+// partOfRelation reuses the listing and decoding of owner, a relation over
+// the same kind.
+func partOfRelation(owner *relation) *relation {
+	kind := owner.kind
+	return &relation{
+		name:   kind + "-helm-release",
+		kind:   kind,
+		edges:  []EdgeKind{PartOf},
+		each:   owner.each,
+		decode: owner.decode,
+		derive: func(r *resolver, obj metav1.Object) []Edge { return r.partOf(kind, obj) },
+	}
+}
+
+// partOf links obj to the Helm release that manages it. A release Secret
+// is linked to the release it stores.
+func (r *resolver) partOf(kind string, obj metav1.Object) []Edge {
+	key, ok := releaseOf(obj)
+	var attrs map[string]string
+	if s, isSecret := obj.(*corev1.Secret); isSecret && s.Type == helmReleaseType {
+		if name := s.Labels["name"]; name != "" {
+			key, ok = KeyOf("HelmRelease", s.Namespace, name), true
+			attrs = map[string]string{"revision": s.Labels["version"]}
+		}
+	}
+	if !ok {
+		return nil
+	}
+	return []Edge{{
+		From:  r.node(obj.GetNamespace(), obj.GetName(), kind),
+		To:    r.g.AddKey(key, ""),
+		Kind:  PartOf,
+		Attrs: attrs,
+	}}
+}
+
+// releaseOf returns the release named by obj's Helm annotations. Objects
+// without a release-namespace annotation belong to a release in their own
+// namespace.
+func releaseOf(obj metav1.Object) (NodeKey, bool) {
+	ann := obj.GetAnnotations()
+	name := ann[helmReleaseName]
+	if name == "" {
+		return NodeKey{}, false
+	}
+	ns := ann[helmReleaseNamespace]
+	if ns == "" {
+		ns = obj.GetNamespace()
+	}
+	return KeyOf("HelmRelease", ns, name), true
+}
+
+// releaseNamed returns the release an event on obj of kind can change: the
+// one a release Secret stores, or the one obj's annotations name.
+func releaseNamed(kind string, obj *unstructured.Unstructured) (NodeKey, bool) {
+	if kind == "Secret" {
+		typ, _, _ := unstructured.NestedString(obj.Object, "type")
+		if name := obj.GetLabels()["name"]; corev1.SecretType(typ) == helmReleaseType && name != "" {
+			return KeyOf("HelmRelease", obj.GetNamespace(), name), true
+		}
+	}
+	return releaseOf(obj)
+}
+
+// helmRelease is the part of a decoded release payload the graph keeps.
+type helmRelease struct {
+	Info struct {
+		Status string `json:"status"`
+	} `json:"info"`
+	Chart struct {
+		Metadata struct {
+			Name       string `json:"name"`
+			Version    string `json:"version"`
+			AppVersion string `json:"appVersion"`
+		} `json:"metadata"`
+	} `json:"chart"`
+}
+
+// decodeRelease decodes the "release" key of a Helm release Secret: base64
+// text of a gzip-compressed JSON document.
+func decodeRelease(s *corev1.Secret) (*helmRelease, error) {
+	raw, err := base64.StdEncoding.DecodeString(string(s.Data["release"]))
+	if err != nil {
+		return nil, err
+	}
+	if bytes.HasPrefix(raw, []byte{0x1f, 0x8b}) {
+		zr, err := gzip.NewReader(bytes.NewReader(raw))
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		if raw, err = io.ReadAll(zr); err != nil {
+			return nil, err
+		}
+	}
+	var rel helmRelease
+	if err := json.Unmarshal(raw, &rel); err != nil {
+		return nil, err
+	}
+	return &rel, nil
+}
+
+// markReleaseSecret decodes the chart metadata of the release Secret s onto
+// its node. AddObject replaces the properties with each new version of the
+// Secret, so a version already decoded is skipped.
+func markReleaseSecret(g *Graph, s *corev1.Secret) {
+	if s.Type != helmReleaseType || s.Labels["name"] == "" {
+		return
+	}
+	uid := KeyOf("Secret", s.Namespace, s.Name).ID()
+	if n, ok := g.Node(uid); !ok || n.Props[releasePrefix+"chart"] != "" {
+		return
+	}
+	rel, err := decodeRelease(s)
+	if err != nil {
+		return
+	}
+	g.SetProps(uid, map[string]string{
+		releasePrefix + "chart":        rel.Chart.Metadata.Name,
+		releasePrefix + "chartVersion": rel.Chart.Metadata.Version,
+		releasePrefix + "appVersion":   rel.Chart.Metadata.AppVersion,
+		releasePrefix + "status":       rel.Info.Status,
+	})
+}
+
+// markHelmReleases sets the helmProps of the HelmRelease nodes among uids
+// from their part-of edges, and removes the nodes that have none left. The
+// newest release Secret (highest revision) wins; releases without a decoded
+// Secret fall back to the helm.sh/chart label of their members, e.g.
+// "nova-0.3.12".
+func markHelmReleases(g *Graph, uids []string) {
+	for _, uid := range uids {
+		if n, ok := g.Node(uid); !ok || n.Type != "HelmRelease" {
+			continue
+		}
+		edges := g.Incident(uid)
+		if len(edges) == 0 {
+			g.RemoveNode(uid)
+			continue
+		}
+		var latest Node
+		newest := -1
+		for _, e := range edges {
+			rev, ok := e.Attrs["revision"]
+			if e.Kind != PartOf || e.To != uid || !ok {
+				continue
+			}
+			if n, _ := strconv.Atoi(rev); n > newest {
+				newest = n
+				latest, _ = g.Node(e.From)
+			}
+		}
+		props := make(map[string]string, len(helmProps))
+		for _, k := range helmProps {
+			props[k] = latest.Props[releasePrefix+k]
+		}
+		if newest >= 0 {
+			props["revision"] = latest.Props["label.version"]
+			if status := latest.Props["label.status"]; status != "" {
+				props["status"] = status
+			}
+		}
+		if props["chart"] == "" {
+			props["chart"], props["chartVersion"] = memberChart(g, uid)
+		}
+		g.SetProps(uid, props)
+	}
+}
+
+// memberChart splits the first helm.sh/chart label found on a member of
+// the release into chart name and version.
+func memberChart(g *Graph, uid string) (string, string) {
+	var charts []string
+	for _, e := range g.Incident(uid) {
+		if e.Kind != PartOf || e.To != uid {
+			continue
+		}
+		if n, _ := g.Node(e.From); n.Props["label.helm.sh/chart"] != "" {
+			charts = append(charts, n.Props["label.helm.sh/chart"])
+		}
+	}
+	if len(charts) == 0 {
+		return "", ""
+	}
+	sort.Strings(charts)
+	c := charts[0]
+	// 차트 이름과 버전(예: 1.0.0-rc1) 모두 '-'를 포함할 수 있으므로
+	// 뒤에서부터 숫자로 시작하는 부분을 버전의 시작으로 본다
+	for i := strings.LastIndex(c, "-"); i > 0; i = strings.LastIndex(c[:i], "-") {
+		if i+1 < len(c) && c[i+1] >= '0' && c[i+1] <= '9' {
+			return c[:i], c[i+1:]
+		}
+	}
+	return c, ""
+}
@@ -0,0 +1,146 @@
+package collector
+
+import (
+	"sync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// registerMu guards kindGroups and clusterScoped, which CRDConfig.register
+// extends with custom kinds, and the custom relations in crd.go.
+var registerMu sync.RWMutex
+
+// kindGroups maps every kind the collector emits to its API group ("" = core).
+var kindGroups = map[string]string{
+	"Pod":                     "",
+	"Service":                 "",
+	"ConfigMap":               "",
+	"Secret":                  "",
+	"ServiceAccount":          "",
+	"PersistentVolumeClaim":   "",
+	"PersistentVolume":        "",
+	"Namespace":               "",
+	"Node":                    "",
+	"Deployment":              "apps",
+	"ReplicaSet":              "apps",
+	"DaemonSet":               "apps",
+	"StatefulSet":             "apps",
+	"ControllerRevision":      "apps",
+	"Job":                     "batch",
+	"CronJob":                 "batch",
+	"Ingress":                 "networking.k8s.io",
+	"IngressClass":            "networking.k8s.io",
+	"GatewayClass":            "gateway.networking.k8s.io",
+	"Gateway":                 "gateway.networking.k8s.io",
+	"HTTPRoute":               "gateway.networking.k8s.io",
+	"GRPCRoute":               "gateway.networking.k8s.io",
+	"ReferenceGrant":          "gateway.networking.k8s.io",
+	"NetworkPolicy":           "networking.k8s.io",
+	"IPBlock":                 "networking.k8s.io",
+	"EndpointSlice":           "discovery.k8s.io",
+	"HorizontalPodAutoscaler": "autoscaling",
+	"PodDisruptionBudget":     "policy",
+	"Zone":                    "topology.kubernetes.io",
+	"Region":                  "topology.kubernetes.io",
+	"StorageClass":            "storage.k8s.io",
+	"VolumeAttachment":        "storage.k8s.io",
+	"Role":                    "rbac.authorization.k8s.io",
+	"ClusterRole":             "rbac.authorization.k8s.io",
+	"RoleBinding":             "rbac.authorization.k8s.io",
+	"ClusterRoleBinding":      "rbac.authorization.k8s.io",
+	"User":                    "rbac.authorization.k8s.io",
+	"Group":                   "rbac.authorization.k8s.io",
+	"SecretProviderClass":     "secrets-store.csi.x-k8s.io",
+	"HelmRelease":             "helm.sh",
+	"OpenStackService":        "openstack.org",
+	"OpenStackComponent":      "openstack.org",
+}
+
+// clusterScoped lists the registered kinds that have no namespace.
+var clusterScoped = map[string]bool{
+	"Namespace":          true,
+	"Node":               true,
+	"PersistentVolume":   true,
+	"StorageClass":       true,
+	"VolumeAttachment":   true,
+	"IngressClass":       true,
+	"GatewayClass":       true,
+	"Zone":               true,
+	"Region":             true,
+	"IPBlock":            true,
+	"ClusterRole":        true,
+	"ClusterRoleBinding": true,
+	"User":               true,
+	"Group":              true,
+	"APIResource":        true,
+	"OpenStackService":   true,
+	"OpenStackComponent": true,
+}
+
+// GroupForKind returns the API group registered for kind.
+func GroupForKind(kind string) string {
+	registerMu.RLock()
+	defer registerMu.RUnlock()
+	return kindGroups[kind]
+}
+
+// isClusterScoped reports whether kind is registered as cluster-scoped.
+func isClusterScoped(kind string) bool {
+	registerMu.RLock()
+	defer registerMu.RUnlock()
+	return clusterScoped[kind]
+}
+
+// OwnerKey returns the key of the object ref points at, as seen from an
+// object in namespace ns. Owners live in the same namespace as their
+// dependents unless they are cluster-scoped (e.g. the Node owning a
+// mirror pod). The group comes from the reference's apiVersion, so a CRD
+// that reuses a built-in kind name keeps its own group.
+func OwnerKey(ns string, ref metav1.OwnerReference) NodeKey {
+	return RefKey(ns, ref.APIVersion, ref.Kind, ref.Name)
+}
+
+// RefKey resolves an object reference made from namespace ns, such as an
+// ownerReference or an HPA scaleTargetRef, with the same rules as OwnerKey.
+// The registry only supplies the group when apiVersion is empty or invalid.
+func RefKey(ns, apiVersion, kind, name string) NodeKey {
+	var group string
+	if gv, err := schema.ParseGroupVersion(apiVersion); apiVersion != "" && err == nil {
+		group = gv.Group
+	} else {
+		group = GroupForKind(kind)
+	}
+	if isClusterScoped(kind) {
+		ns = ""
+	}
+	return NodeKey{Group: group, Kind: kind, Namespace: ns, Name: name}
+}
+
+// NodeKey identifies an object by API group, kind, namespace and name.
+// Two objects that differ in any of these fields never share a node.
+type NodeKey struct {
+	Group     string
+	Kind      string
+	Namespace string
+	Name      string
+}
+
+// KeyOf builds the NodeKey for kind, filling the group from the registry.
+func KeyOf(kind, ns, name string) NodeKey {
+	return NodeKey{Group: GroupForKind(kind), Kind: kind, Namespace: ns, Name: name}
+}
+
+// ID renders the key as "Kind.group/namespace/name" ("Kind.group/name" for
+// cluster-scoped objects). Kinds never contain '.' and object names never
+// contain '/', so distinct keys always render to distinct IDs. The synthetic
+// IPBlock, APIResource and OpenStackComponent kinds ("10.0.0.0/8",
+// "pods/exec", "compute/api") are the exception, but they are always
+// cluster-scoped and cannot collide.
+func (k NodeKey) ID() string {
+	gk := schema.GroupKind{Group: k.Group, Kind: k.Kind}.String()
+	if k.Namespace == "" {
+		return gk + "/" + k.Name
+	}
+	return gk + "/" + k.Namespace + "/" + k.Name
+}
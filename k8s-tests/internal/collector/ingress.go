@@ -0,0 +1,159 @@
+package collector
+
+import (
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	netv1 "k8s.io/api/networking/v1"
+)
+
+// North-south entry points besides Ingress -routes-> Service:
+//
+//	Ingress -uses-> IngressClass {source: spec|annotation|default}
+//	Ingress -reads-> Secret {hosts} (spec.tls)
+//	IngressClass|GatewayClass -served-by-> Pod {controller}
+//
+// Controllers announce the class they implement only by name, so the pods
+// serving a class are found by their --controller-class style flags, or, for
+// pods that declare none, by the standard labels of well-known controllers
+// (controllerLabels).
+
+const (
+	ingressClassAnnotation = "kubernetes.io/ingress.class"
+	defaultClassAnnotation = "ingressclass.kubernetes.io/is-default-class"
+)
+
+// controllerLabels maps well-known controller names to the labels of
+// their pods when installed from the upstream charts.
+var controllerLabels = map[string]map[string]string{
+	"k8s.io/ingress-nginx":                          {"app.kubernetes.io/name": "ingress-nginx", "app.kubernetes.io/component": "controller"},
+	"traefik.io/ingress-controller":                 {"app.kubernetes.io/name": "traefik"},
+	"traefik.io/gateway-controller":                 {"app.kubernetes.io/name": "traefik"},
+	"haproxy.org/ingress-controller":                {"app.kubernetes.io/name": "kubernetes-ingress"},
+	"gateway.envoyproxy.io/gatewayclass-controller": {"control-plane": "envoy-gateway"},
+	"istio.io/gateway-controller":                   {"app": "istiod"},
+	"gateway.nginx.org/nginx-gateway-controller":    {"app.kubernetes.io/name": "nginx-gateway"},
+}
+
+// controllerPods returns the pods running the controller named controller.
+func (r *resolver) controllerPods(controller string) []*corev1.Pod {
+	return cached(r, "Pod@controller="+controller, func() []*corev1.Pod {
+		var out []*corev1.Pod
+		want := controllerLabels[controller]
+		pods := r.pods()
+		for i := range pods {
+			if servesController(&pods[i], controller, want) {
+				out = append(out, &pods[i])
+			}
+		}
+		return out
+	})
+}
+
+// servesController reports whether pod runs controller. A --controller-class
+// style flag naming a controller decides on its own, so a second install of
+// the same chart serving another class is not matched by its labels; pods
+// without one are matched by want, the labels from controllerLabels.
+func servesController(pod *corev1.Pod, controller string, want map[string]string) bool {
+	declared := false
+	for _, c := range pod.Spec.Containers {
+		for _, arg := range append(append([]string{}, c.Command...), c.Args...) {
+			flag, value, ok := strings.Cut(arg, "=")
+			// controller 이름은 "example.com/name" 형식
+			if !ok || !strings.HasPrefix(flag, "--") || !strings.Contains(flag, "controller") || !strings.Contains(value, "/") {
+				continue
+			}
+			if value == controller {
+				return true
+			}
+			declared = true
+		}
+	}
+	if declared || len(want) == 0 {
+		return false
+	}
+	for k, v := range want {
+		if pod.Labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+func deriveIngressClassPods(r *resolver, ic *netv1.IngressClass) []Edge {
+	from := r.node("", ic.Name, "IngressClass")
+	var out []Edge
+	for _, pod := range r.controllerPods(ic.Spec.Controller) {
+		out = append(out, Edge{
+			From: from, To: r.node(pod.Namespace, pod.Name, "Pod"), Kind: ServedBy,
+			Attrs: map[string]string{"controller": ic.Spec.Controller},
+		})
+	}
+	return out
+}
+
+// deriveIngressClass links an Ingress to the class named in
+// spec.ingressClassName, the legacy annotation, or else the cluster's
+// default IngressClass.
+func deriveIngressClass(r *resolver, ing *netv1.Ingress) []Edge {
+	class, source := "", ""
+	switch {
+	case ing.Spec.IngressClassName != nil && *ing.Spec.IngressClassName != "":
+		class, source = *ing.Spec.IngressClassName, "spec"
+	case ing.Annotations[ingressClassAnnotation] != "":
+		class, source = ing.Annotations[ingressClassAnnotation], "annotation"
+	default:
+		for _, ic := range r.ingressClasses() {
+			if ic.Annotations[defaultClassAnnotation] == "true" {
+				class, source = ic.Name, "default"
+				break
+			}
+		}
+	}
+	if class == "" {
+		return nil
+	}
+	return []Edge{{
+		From:  r.node(ing.Namespace, ing.Name, "Ingress"),
+		To:    r.node("", class, "IngressClass"),
+		Kind:  Uses,
+		Attrs: map[string]string{"source": source},
+	}}
+}
+
+func deriveIngressTLS(r *resolver, ing *netv1.Ingress) []Edge {
+	from := r.node(ing.Namespace, ing.Name, "Ingress")
+	var out []Edge
+	for _, tls := range ing.Spec.TLS {
+		if tls.SecretName == "" {
+			continue // 컨트롤러 기본 인증서 사용
+		}
+		hosts := "*"
+		if len(tls.Hosts) > 0 {
+			hosts = strings.Join(tls.Hosts, ",")
+		}
+		out = append(out, Edge{
+			From: from, To: r.node(ing.Namespace, tls.SecretName, "Secret"), Kind: Reads,
+			Attrs: map[string]string{"hosts": hosts},
+		})
+	}
+	return out
+}
+
+// ingressBackend returns the node an Ingress backend points at: a Service
+// (with its port) or, for resource backends, any object in the Ingress's
+// namespace such as a storage bucket.
+func (r *resolver) ingressBackend(ns string, b netv1.IngressBackend) (to, port string) {
+	switch {
+	case b.Service != nil:
+		return r.node(ns, b.Service.Name, "Service"), backendPort(b.Service.Port)
+	case b.Resource != nil:
+		group := ""
+		if b.Resource.APIGroup != nil {
+			group = *b.Resource.APIGroup
+		}
+		key := NodeKey{Group: group, Kind: b.Resource.Kind, Namespace: ns, Name: b.Resource.Name}
+		return r.g.AddKey(key, ""), ""
+	}
+	return "", ""
+}
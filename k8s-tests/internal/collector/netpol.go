@@ -0,0 +1,393 @@
+package collector
+
+import (
+	"net"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	netv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// Endpoint is one side of a connection: a pod, or an address outside the
+// cluster when Pod is nil.
+type Endpoint struct {
+	Pod *corev1.Pod
+	IP  string
+}
+
+// PodEndpoint returns the endpoint of pod, addressed by its primary IP.
+func PodEndpoint(pod *corev1.Pod) Endpoint {
+	return Endpoint{Pod: pod, IP: pod.Status.PodIP}
+}
+
+// PolicyEvaluator decides reachability under a set of NetworkPolicies with
+// the Kubernetes semantics:
+//
+//   - a pod is isolated for a direction only if some policy in its own
+//     namespace selects it and lists that direction in policyTypes;
+//   - traffic is allowed if the source's egress and the destination's
+//     ingress both allow it, each being the union of all selecting policies;
+//   - peers select pods in the policy's namespace unless a namespaceSelector
+//     is given; ipBlock peers match by address;
+//   - named ports resolve against the destination pod's container ports.
+type PolicyEvaluator struct {
+	policies []netv1.NetworkPolicy
+	nsLabels map[string]labels.Set
+}
+
+// NewPolicyEvaluator builds an evaluator. Namespaces supply the labels
+// namespaceSelectors match; every namespace, listed or not, carries the
+// automatic kubernetes.io/metadata.name label.
+func NewPolicyEvaluator(policies []netv1.NetworkPolicy, namespaces []corev1.Namespace) *PolicyEvaluator {
+	ev := &PolicyEvaluator{policies: policies, nsLabels: make(map[string]labels.Set, len(namespaces))}
+	for _, ns := range namespaces {
+		l := labels.Set{corev1.LabelMetadataName: ns.Name}
+		for k, v := range ns.Labels {
+			l[k] = v
+		}
+		ev.nsLabels[ns.Name] = l
+	}
+	return ev
+}
+
+// Allowed reports whether src may open a connection to dst on proto/port.
+func (ev *PolicyEvaluator) Allowed(src, dst Endpoint, proto corev1.Protocol, port int32) bool {
+	return ev.allowedDir(src, dst, proto, port, netv1.PolicyTypeEgress) &&
+		ev.allowedDir(src, dst, proto, port, netv1.PolicyTypeIngress)
+}
+
+// allowedDir evaluates one side: the egress policies of src or the ingress
+// policies of dst. Endpoints outside the cluster are never isolated.
+func (ev *PolicyEvaluator) allowedDir(src, dst Endpoint, proto corev1.Protocol, port int32, dir netv1.PolicyType) bool {
+	subject, peer := src, dst
+	if dir == netv1.PolicyTypeIngress {
+		subject, peer = dst, src
+	}
+	if subject.Pod == nil {
+		return true
+	}
+	isolated := false
+	for i := range ev.policies {
+		np := &ev.policies[i]
+		if !hasPolicyType(np, dir) || !policySelects(np, subject.Pod) {
+			continue
+		}
+		isolated = true
+		for _, rule := range policyRules(np, dir) {
+			if ev.peersMatch(np.Namespace, rule.peers, peer) &&
+				len(rulePorts(rule.ports, dst.Pod, proto, port)) > 0 {
+				return true
+			}
+		}
+	}
+	return !isolated
+}
+
+// policyRule is an ingress or egress rule with its peers (from/to).
+type policyRule struct {
+	peers []netv1.NetworkPolicyPeer
+	ports []netv1.NetworkPolicyPort
+}
+
+func policyRules(np *netv1.NetworkPolicy, dir netv1.PolicyType) []policyRule {
+	var out []policyRule
+	if dir == netv1.PolicyTypeIngress {
+		for _, r := range np.Spec.Ingress {
+			out = append(out, policyRule{peers: r.From, ports: r.Ports})
+		}
+	} else {
+		for _, r := range np.Spec.Egress {
+			out = append(out, policyRule{peers: r.To, ports: r.Ports})
+		}
+	}
+	return out
+}
+
+// hasPolicyType applies the policyTypes defaulting: Ingress always, Egress
+// only when egress rules are present.
+func hasPolicyType(np *netv1.NetworkPolicy, dir netv1.PolicyType) bool {
+	if len(np.Spec.PolicyTypes) == 0 {
+		return dir == netv1.PolicyTypeIngress || len(np.Spec.Egress) > 0
+	}
+	for _, t := range np.Spec.PolicyTypes {
+		if t == dir {
+			return true
+		}
+	}
+	return false
+}
+
+// policySelects reports whether np applies to pod. The empty podSelector
+// selects every pod of the policy's namespace and nothing outside it.
+func policySelects(np *netv1.NetworkPolicy, pod *corev1.Pod) bool {
+	return pod.Namespace == np.Namespace && selectorMatches(&np.Spec.PodSelector, pod.Labels)
+}
+
+// peersMatch reports whether ep is one of peers, resolved from namespace ns.
+// No peers at all means every source or destination.
+func (ev *PolicyEvaluator) peersMatch(ns string, peers []netv1.NetworkPolicyPeer, ep Endpoint) bool {
+	if len(peers) == 0 {
+		return true
+	}
+	for _, p := range peers {
+		if ev.peerMatches(ns, p, ep) {
+			return true
+		}
+	}
+	return false
+}
+
+func (ev *PolicyEvaluator) peerMatches(ns string, peer netv1.NetworkPolicyPeer, ep Endpoint) bool {
+	if peer.IPBlock != nil {
+		return ipBlockContains(peer.IPBlock.CIDR, peer.IPBlock.Except, ep.IP)
+	}
+	if ep.Pod == nil {
+		return false
+	}
+	if peer.NamespaceSelector != nil {
+		if !selectorMatches(peer.NamespaceSelector, ev.namespaceLabels(ep.Pod.Namespace)) {
+			return false
+		}
+	} else if ep.Pod.Namespace != ns {
+		return false
+	}
+	return peer.PodSelector == nil || selectorMatches(peer.PodSelector, ep.Pod.Labels)
+}
+
+func (ev *PolicyEvaluator) namespaceLabels(ns string) labels.Set {
+	if l, ok := ev.nsLabels[ns]; ok {
+		return l
+	}
+	return labels.Set{corev1.LabelMetadataName: ns}
+}
+
+func selectorMatches(sel *metav1.LabelSelector, l map[string]string) bool {
+	s, err := metav1.LabelSelectorAsSelector(sel)
+	if err != nil {
+		return false
+	}
+	return s.Matches(labels.Set(l))
+}
+
+// ipBlockContains reports whether ip lies in cidr and outside all of the
+// except ranges.
+func ipBlockContains(cidr string, except []string, ip string) bool {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return false
+	}
+	if _, block, err := net.ParseCIDR(cidr); err != nil || !block.Contains(addr) {
+		return false
+	}
+	for _, ex := range except {
+		if _, block, err := net.ParseCIDR(ex); err == nil && block.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// rulePorts returns the rule ports, rendered as "TCP/5432" or "TCP/8000-8080",
+// that admit proto/port towards dst. A zero port asks for every port the
+// rule admits instead of one in particular. Named ports resolve against the
+// container ports of dst and never match when dst is not a pod. A rule
+// without ports admits everything and returns "*".
+func rulePorts(ports []netv1.NetworkPolicyPort, dst *corev1.Pod, proto corev1.Protocol, port int32) []string {
+	if len(ports) == 0 {
+		return []string{"*"}
+	}
+	var out []string
+	for _, p := range ports {
+		pproto := corev1.ProtocolTCP
+		if p.Protocol != nil {
+			pproto = *p.Protocol
+		}
+		if proto != "" && pproto != proto {
+			continue
+		}
+		switch {
+		case p.Port == nil:
+			out = append(out, string(pproto)+"/*")
+		case p.Port.Type == intstr.String:
+			num, ok := namedPort(dst, p.Port.StrVal, pproto)
+			if ok && (port == 0 || port == num) {
+				out = append(out, string(pproto)+"/"+strconv.Itoa(int(num)))
+			}
+		default:
+			lo, hi := p.Port.IntVal, p.Port.IntVal
+			if p.EndPort != nil {
+				hi = *p.EndPort
+			}
+			if port != 0 && (port < lo || port > hi) {
+				continue
+			}
+			r := strconv.Itoa(int(lo))
+			if hi != lo {
+				r += "-" + strconv.Itoa(int(hi))
+			}
+			out = append(out, string(pproto)+"/"+r)
+		}
+	}
+	return out
+}
+
+func namedPort(pod *corev1.Pod, name string, proto corev1.Protocol) (int32, bool) {
+	if pod == nil {
+		return 0, false
+	}
+	for _, c := range pod.Spec.Containers {
+		for _, cp := range c.Ports {
+			cproto := cp.Protocol
+			if cproto == "" {
+				cproto = corev1.ProtocolTCP
+			}
+			if cp.Name == name && cproto == proto {
+				return cp.ContainerPort, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// policyPorts renders rule ports as "TCP/80,UDP/53,TCP/8000-8080". Named
+// ports stay unresolved ("TCP/metrics"); "" means all ports.
+func policyPorts(ports []netv1.NetworkPolicyPort) string {
+	out := make([]string, 0, len(ports))
+	for _, p := range ports {
+		proto := "TCP"
+		if p.Protocol != nil {
+			proto = string(*p.Protocol)
+		}
+		port := "*"
+		if p.Port != nil {
+			port = p.Port.String()
+			if p.EndPort != nil {
+				port += "-" + strconv.Itoa(int(*p.EndPort))
+			}
+		}
+		out = append(out, proto+"/"+port)
+	}
+	return strings.Join(out, ",")
+}
+
+// policyTypes renders the directions np isolates, after defaulting.
+func policyTypes(np *netv1.NetworkPolicy) string {
+	var out []string
+	for _, dir := range []netv1.PolicyType{netv1.PolicyTypeIngress, netv1.PolicyTypeEgress} {
+		if hasPolicyType(np, dir) {
+			out = append(out, string(dir))
+		}
+	}
+	return strings.Join(out, ",")
+}
+
+// deriveNetpolSelects links a policy to the pods it isolates.
+func deriveNetpolSelects(r *resolver, np *netv1.NetworkPolicy) []Edge {
+	from := r.node(np.Namespace, np.Name, "NetworkPolicy")
+	types := policyTypes(np)
+	var out []Edge
+	for _, pod := range r.podsIn(np.Namespace) {
+		if !policySelects(np, pod) {
+			continue
+		}
+		out = append(out, Edge{
+			From:  from,
+			To:    r.node(pod.Namespace, pod.Name, "Pod"),
+			Kind:  Selects,
+			Attrs: map[string]string{"policyTypes": types},
+		})
+	}
+	return out
+}
+
+// deriveNetpolPeers links a policy to the peer groups its rules admit:
+// AdmitsFrom for ingress rules, AdmitsTo for egress rules. A pod peer
+// becomes an edge to the Namespace it is looked up in, carrying the pod
+// selector; an ipBlock peer becomes an IPBlock node. A rule without peers
+// admits every namespace and 0.0.0.0/0. Edges are numbered by rule so
+// that rules with different ports stay apart. Pairwise reachability is not
+// materialized; PolicyEvaluator derives it on demand.
+func deriveNetpolPeers(r *resolver, np *netv1.NetworkPolicy) []Edge {
+	ev := r.policyEvaluator()
+	from := r.node(np.Namespace, np.Name, "NetworkPolicy")
+	var out []Edge
+	for _, dir := range []netv1.PolicyType{netv1.PolicyTypeIngress, netv1.PolicyTypeEgress} {
+		if !hasPolicyType(np, dir) {
+			continue
+		}
+		kind := AdmitsFrom
+		if dir == netv1.PolicyTypeEgress {
+			kind = AdmitsTo
+		}
+		for i, rule := range policyRules(np, dir) {
+			edge := func(to string, attrs map[string]string) {
+				attrs["rule"] = strconv.Itoa(i)
+				if ports := policyPorts(rule.ports); ports != "" {
+					attrs["ports"] = ports
+				}
+				out = append(out, Edge{From: from, To: to, Kind: kind, Attrs: attrs})
+			}
+			peers := rule.peers
+			if len(peers) == 0 {
+				// 모든 namespace 와 외부 주소
+				peers = []netv1.NetworkPolicyPeer{
+					{NamespaceSelector: &metav1.LabelSelector{}},
+					{IPBlock: &netv1.IPBlock{CIDR: "0.0.0.0/0"}},
+				}
+			}
+			for _, peer := range peers {
+				switch {
+				case peer.IPBlock != nil:
+					attrs := map[string]string{}
+					if len(peer.IPBlock.Except) > 0 {
+						attrs["except"] = strings.Join(peer.IPBlock.Except, ",")
+					}
+					edge(r.node("", peer.IPBlock.CIDR, "IPBlock"), attrs)
+				case !peerSelectorsValid(peer):
+					// 파싱할 수 없는 selector는 어떤 pod도 고르지 않음 (PolicyEvaluator와 동일)
+				case peer.NamespaceSelector != nil:
+					for _, ns := range r.namespaceNames() {
+						if selectorMatches(peer.NamespaceSelector, ev.namespaceLabels(ns)) {
+							edge(r.node("", ns, "Namespace"), peerAttrs(peer))
+						}
+					}
+				default:
+					edge(r.node("", np.Namespace, "Namespace"), peerAttrs(peer))
+				}
+			}
+		}
+	}
+	return out
+}
+
+// peerSelectorsValid reports whether the selectors of peer parse. A peer
+// with an invalid selector admits nothing and gets no edge.
+func peerSelectorsValid(peer netv1.NetworkPolicyPeer) bool {
+	for _, sel := range []*metav1.LabelSelector{peer.NamespaceSelector, peer.PodSelector} {
+		if sel == nil {
+			continue
+		}
+		if _, err := metav1.LabelSelectorAsSelector(sel); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// peerAttrs records which pods of the peer namespace are admitted. No
+// podSelector attribute means all of them.
+func peerAttrs(peer netv1.NetworkPolicyPeer) map[string]string {
+	attrs := map[string]string{}
+	if peer.PodSelector == nil {
+		return attrs
+	}
+	sel, _ := metav1.LabelSelectorAsSelector(peer.PodSelector)
+	if s := sel.String(); s != "" {
+		attrs["podSelector"] = s
+	}
+	return attrs
+}
@@ -0,0 +1,305 @@
+package collector
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	netv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/kaist2025/k8s-e2e-tests/internal/k8sclient"
+)
+
+func testPod(ns, name, ip string, labels map[string]string, ports ...corev1.ContainerPort) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: ns, Name: name, Labels: labels},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "c", Ports: ports}}},
+		Status:     corev1.PodStatus{PodIP: ip},
+	}
+}
+
+// policyCheck asks whether src may connect to dst on proto/port.
+type policyCheck struct {
+	name     string
+	src, dst Endpoint
+	proto    corev1.Protocol
+	port     int32
+	want     bool
+}
+
+// policyCase is a set of NetworkPolicies and the verdicts they imply.
+type policyCase struct {
+	name     string
+	policies string
+	checks   []policyCheck
+}
+
+// policyCases follow the upstream NetworkPolicy documentation and the
+// kubernetes-network-policy-recipes examples. Both PolicyEvaluator and
+// Snapshot.Reach are checked against them.
+func policyCases() ([]policyCase, []corev1.Namespace) {
+	var (
+		web       = PodEndpoint(testPod("default", "web", "10.0.1.10", map[string]string{"app": "web"}, corev1.ContainerPort{Name: "http", ContainerPort: 8080}))
+		db        = PodEndpoint(testPod("default", "db", "10.0.1.11", map[string]string{"app": "db"}))
+		client    = PodEndpoint(testPod("default", "client", "10.0.1.12", map[string]string{"app": "client"}))
+		opsClient = PodEndpoint(testPod("ops", "client", "10.0.2.12", map[string]string{"app": "client"}))
+		inside    = Endpoint{IP: "10.0.1.1"}
+		excepted  = Endpoint{IP: "10.0.5.1"}
+		outside   = Endpoint{IP: "192.168.0.1"}
+	)
+	namespaces := []corev1.Namespace{{ObjectMeta: metav1.ObjectMeta{Name: "ops", Labels: map[string]string{"team": "ops"}}}}
+
+	return []policyCase{
+		{
+			name: "no policies allow everything",
+			checks: []policyCheck{
+				{"client->web", client, web, corev1.ProtocolTCP, 8080, true},
+				{"ops->db", opsClient, db, corev1.ProtocolUDP, 53, true},
+			},
+		},
+		{
+			name: "deny all traffic to an application",
+			policies: `
+kind: NetworkPolicy
+apiVersion: networking.k8s.io/v1
+metadata: {name: web-deny-all, namespace: default}
+spec:
+  podSelector: {matchLabels: {app: web}}
+  ingress: []`,
+			checks: []policyCheck{
+				{"client->web", client, web, corev1.ProtocolTCP, 8080, false},
+				{"client->db unaffected", client, db, corev1.ProtocolTCP, 5432, true},
+				{"web egress unaffected", web, db, corev1.ProtocolTCP, 5432, true},
+			},
+		},
+		{
+			name: "limit traffic to an application",
+			policies: `
+kind: NetworkPolicy
+apiVersion: networking.k8s.io/v1
+metadata: {name: web-allow-client, namespace: default}
+spec:
+  podSelector: {matchLabels: {app: web}}
+  ingress:
+  - from:
+    - podSelector: {matchLabels: {app: client}}`,
+			checks: []policyCheck{
+				{"client->web", client, web, corev1.ProtocolTCP, 8080, true},
+				{"db->web", db, web, corev1.ProtocolTCP, 8080, false},
+				{"podSelector peer stays in the policy namespace", opsClient, web, corev1.ProtocolTCP, 8080, false},
+			},
+		},
+		{
+			name: "deny all non-whitelisted traffic in a namespace",
+			policies: `
+kind: NetworkPolicy
+apiVersion: networking.k8s.io/v1
+metadata: {name: default-deny-all, namespace: default}
+spec:
+  podSelector: {}
+  ingress: []`,
+			checks: []policyCheck{
+				{"client->db", client, db, corev1.ProtocolTCP, 5432, false},
+				{"db->client", db, client, corev1.ProtocolTCP, 80, false},
+				{"other namespace not isolated", client, opsClient, corev1.ProtocolTCP, 80, true},
+			},
+		},
+		{
+			name: "deny traffic from other namespaces",
+			policies: `
+kind: NetworkPolicy
+apiVersion: networking.k8s.io/v1
+metadata: {name: deny-from-other-namespaces, namespace: default}
+spec:
+  podSelector: {}
+  ingress:
+  - from:
+    - podSelector: {}`,
+			checks: []policyCheck{
+				{"same namespace", client, web, corev1.ProtocolTCP, 8080, true},
+				{"other namespace", opsClient, web, corev1.ProtocolTCP, 8080, false},
+				{"outside the cluster", outside, web, corev1.ProtocolTCP, 8080, false},
+			},
+		},
+		{
+			name: "allow traffic from all namespaces",
+			policies: `
+kind: NetworkPolicy
+apiVersion: networking.k8s.io/v1
+metadata: {name: web-allow-all-namespaces, namespace: default}
+spec:
+  podSelector: {matchLabels: {app: web}}
+  ingress:
+  - from:
+    - namespaceSelector: {}`,
+			checks: []policyCheck{
+				{"other namespace", opsClient, web, corev1.ProtocolTCP, 8080, true},
+				{"outside the cluster", outside, web, corev1.ProtocolTCP, 8080, false},
+			},
+		},
+		{
+			name: "allow traffic from a labelled namespace",
+			policies: `
+kind: NetworkPolicy
+apiVersion: networking.k8s.io/v1
+metadata: {name: web-allow-ops, namespace: default}
+spec:
+  podSelector: {matchLabels: {app: web}}
+  ingress:
+  - from:
+    - namespaceSelector: {matchLabels: {team: ops}}
+  - from:
+    - namespaceSelector: {matchLabels: {kubernetes.io/metadata.name: default}}
+      podSelector: {matchLabels: {app: db}}`,
+			checks: []policyCheck{
+				{"ops namespace", opsClient, web, corev1.ProtocolTCP, 8080, true},
+				{"namespace and pod selector", db, web, corev1.ProtocolTCP, 8080, true},
+				{"namespace matches, pod does not", client, web, corev1.ProtocolTCP, 8080, false},
+			},
+		},
+		{
+			name: "named and numbered ports",
+			policies: `
+kind: NetworkPolicy
+apiVersion: networking.k8s.io/v1
+metadata: {name: web-ports, namespace: default}
+spec:
+  podSelector: {}
+  ingress:
+  - ports:
+    - port: http
+  - ports:
+    - protocol: UDP
+      port: 5000
+      endPort: 5100`,
+			checks: []policyCheck{
+				{"named port", client, web, corev1.ProtocolTCP, 8080, true},
+				{"other tcp port", client, web, corev1.ProtocolTCP, 9090, false},
+				{"named port unknown to the pod", client, db, corev1.ProtocolTCP, 8080, false},
+				{"inside the range", client, db, corev1.ProtocolUDP, 5050, true},
+				{"outside the range", client, db, corev1.ProtocolUDP, 5200, false},
+				{"range is udp only", client, db, corev1.ProtocolTCP, 5050, false},
+			},
+		},
+		{
+			name: "multiple policies are unioned",
+			policies: `
+kind: NetworkPolicy
+apiVersion: networking.k8s.io/v1
+metadata: {name: web-allow-client, namespace: default}
+spec:
+  podSelector: {matchLabels: {app: web}}
+  ingress:
+  - from:
+    - podSelector: {matchLabels: {app: client}}
+---
+kind: NetworkPolicy
+apiVersion: networking.k8s.io/v1
+metadata: {name: web-allow-db, namespace: default}
+spec:
+  podSelector: {matchLabels: {app: web}}
+  ingress:
+  - from:
+    - podSelector: {matchLabels: {app: db}}`,
+			checks: []policyCheck{
+				{"first policy", client, web, corev1.ProtocolTCP, 8080, true},
+				{"second policy", db, web, corev1.ProtocolTCP, 8080, true},
+				{"neither", opsClient, web, corev1.ProtocolTCP, 8080, false},
+			},
+		},
+		{
+			name: "deny all egress except DNS",
+			policies: `
+kind: NetworkPolicy
+apiVersion: networking.k8s.io/v1
+metadata: {name: client-egress-dns, namespace: default}
+spec:
+  podSelector: {matchLabels: {app: client}}
+  policyTypes: [Egress]
+  egress:
+  - ports:
+    - {port: 53, protocol: UDP}
+    - {port: 53, protocol: TCP}`,
+			checks: []policyCheck{
+				{"dns", client, db, corev1.ProtocolUDP, 53, true},
+				{"other egress", client, web, corev1.ProtocolTCP, 8080, false},
+				{"external egress", client, outside, corev1.ProtocolTCP, 443, false},
+				{"ingress not isolated", web, client, corev1.ProtocolTCP, 80, true},
+			},
+		},
+		{
+			name: "egress rules imply both policy types",
+			policies: `
+kind: NetworkPolicy
+apiVersion: networking.k8s.io/v1
+metadata: {name: client-to-db, namespace: default}
+spec:
+  podSelector: {matchLabels: {app: client}}
+  egress:
+  - to:
+    - podSelector: {matchLabels: {app: db}}`,
+			checks: []policyCheck{
+				{"allowed egress", client, db, corev1.ProtocolTCP, 5432, true},
+				{"denied egress", client, web, corev1.ProtocolTCP, 8080, false},
+				{"ingress isolated", web, client, corev1.ProtocolTCP, 80, false},
+			},
+		},
+		{
+			name: "ipBlock with except",
+			policies: `
+kind: NetworkPolicy
+apiVersion: networking.k8s.io/v1
+metadata: {name: web-allow-cidr, namespace: default}
+spec:
+  podSelector: {matchLabels: {app: web}}
+  ingress:
+  - from:
+    - ipBlock:
+        cidr: 10.0.0.0/16
+        except: [10.0.5.0/24]`,
+			checks: []policyCheck{
+				{"inside the block", inside, web, corev1.ProtocolTCP, 8080, true},
+				{"excepted", excepted, web, corev1.ProtocolTCP, 8080, false},
+				{"outside the block", outside, web, corev1.ProtocolTCP, 8080, false},
+			},
+		},
+		{
+			name: "invalid peer selector admits nothing",
+			policies: `
+kind: NetworkPolicy
+apiVersion: networking.k8s.io/v1
+metadata: {name: web-allow-broken, namespace: default}
+spec:
+  podSelector: {matchLabels: {app: web}}
+  ingress:
+  - from:
+    - podSelector:
+        matchExpressions: [{key: app, operator: In, values: []}]`,
+			checks: []policyCheck{
+				{"client->web", client, web, corev1.ProtocolTCP, 8080, false},
+			},
+		},
+	}, namespaces
+}
+
+func TestPolicyEvaluator(t *testing.T) {
+	cases, namespaces := policyCases()
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			objs, err := k8sclient.DecodeManifest([]byte(tc.policies))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var policies []netv1.NetworkPolicy
+			for _, o := range objs {
+				policies = append(policies, *o.(*netv1.NetworkPolicy))
+			}
+			ev := NewPolicyEvaluator(policies, namespaces)
+			for _, c := range tc.checks {
+				if got := ev.Allowed(c.src, c.dst, c.proto, c.port); got != c.want {
+					t.Errorf("%s: %s/%d allowed = %v, want %v", c.name, c.proto, c.port, got, c.want)
+				}
+			}
+		})
+	}
+}
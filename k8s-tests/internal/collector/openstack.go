@@ -0,0 +1,215 @@
+package collector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// OpenStack services are modelled on top of the openstack-helm workloads,
+// using the application and component labels every chart sets:
+//
+//	Deployment|StatefulSet|DaemonSet -implements-> OpenStackComponent "compute/api"
+//	OpenStackComponent -part-of-> OpenStackService "compute"
+//	OpenStackService -exposes-> Ingress|Service {interface, region, url}
+//
+// Services are named by their Keystone catalog type. The exposes edges come
+// from the Keystone service catalog (see KeystoneCatalogStage), which is read
+// once at startup; they are not relinked on Ingress or Service events.
+
+// openstackServiceTypes maps openstack-helm application labels to the
+// catalog type of the service they implement.
+var openstackServiceTypes = map[string]string{
+	"aodh":        "alarming",
+	"barbican":    "key-manager",
+	"ceilometer":  "metering",
+	"cinder":      "block-storage",
+	"designate":   "dns",
+	"glance":      "image",
+	"gnocchi":     "metric",
+	"heat":        "orchestration",
+	"horizon":     "dashboard",
+	"ironic":      "baremetal",
+	"keystone":    "identity",
+	"libvirt":     "compute",
+	"magnum":      "container-infra",
+	"manila":      "shared-file-system",
+	"neutron":     "network",
+	"nova":        "compute",
+	"octavia":     "load-balancer",
+	"openvswitch": "network",
+	"placement":   "placement",
+	"swift":       "object-store",
+}
+
+// catalogTypeAliases folds older or versioned catalog types into the names
+// used for service nodes.
+var catalogTypeAliases = map[string]string{
+	"volume":      "block-storage",
+	"volumev2":    "block-storage",
+	"volumev3":    "block-storage",
+	"block-store": "block-storage",
+	"sharev2":     "shared-file-system",
+}
+
+// componentAliases renames components whose label differs from the role it
+// plays, keyed by "application/component".
+var componentAliases = map[string]string{
+	"nova/os-api":    "api",
+	"neutron/server": "api",
+}
+
+var openstackRelations = []*relation{
+	newOpenStackRelation("Deployment", (*resolver).deployments),
+	newOpenStackRelation("StatefulSet", (*resolver).statefulSets),
+	newOpenStackRelation("DaemonSet", (*resolver).daemonSets),
+}
+
+func newOpenStackRelation[T any, P interface {
+	*T
+	metav1.Object
+}](kind string, list func(r *resolver) []T) *relation {
+	return newRelation(kind+"-openstack", kind, []EdgeKind{Implements}, nil, list,
+		func(r *resolver, obj P) []Edge { return r.openstackComponent(kind, obj) })
+}
+
+// openstackComponent links a workload to its OpenStack component and the
+// component to its service. The component edge is returned with every
+// workload of the component and never dropped.
+func (r *resolver) openstackComponent(kind string, obj metav1.Object) []Edge {
+	app, comp := obj.GetLabels()["application"], obj.GetLabels()["component"]
+	svcType, ok := openstackServiceTypes[app]
+	if !ok || comp == "" {
+		return nil
+	}
+	if alias, ok := componentAliases[app+"/"+comp]; ok {
+		comp = alias
+	}
+	comp = strings.ReplaceAll(comp, "_", "-")
+	svc := r.g.AddKey(KeyOf("OpenStackService", "", svcType), "")
+	component := r.g.AddKey(KeyOf("OpenStackComponent", "", svcType+"/"+comp), "")
+	return []Edge{
+		{
+			From:  r.node(obj.GetNamespace(), obj.GetName(), kind),
+			To:    component,
+			Kind:  Implements,
+			Attrs: map[string]string{"application": app, "component": obj.GetLabels()["component"]},
+		},
+		{From: component, To: svc, Kind: PartOf},
+	}
+}
+
+// ───────────────────────── Keystone catalog ────────────────────────────────
+
+// CatalogEntry is one service of the Keystone service catalog.
+type CatalogEntry struct {
+	Type      string            `json:"type"`
+	Name      string            `json:"name"`
+	Endpoints []CatalogEndpoint `json:"endpoints"`
+}
+
+// CatalogEndpoint is one endpoint of a catalog entry.
+type CatalogEndpoint struct {
+	Interface string `json:"interface"` // public, internal, admin
+	Region    string `json:"region"`
+	URL       string `json:"url"`
+}
+
+// LoadKeystoneCatalog reads the service catalog from a file or, for http(s)
+// sources, from Keystone's GET /v3/auth/catalog. The request is
+// authenticated with the token in $OS_TOKEN, if set, and is cancelled with
+// ctx; KeystoneCatalogStage bounds it with keystoneTimeout. Both the catalog
+// response ({"catalog": [...]}) and a token response
+// ({"token": {"catalog": [...]}}) are accepted.
+func LoadKeystoneCatalog(ctx context.Context, source string) ([]CatalogEntry, error) {
+	var doc struct {
+		Catalog []CatalogEntry `json:"catalog"`
+		Token   struct {
+			Catalog []CatalogEntry `json:"catalog"`
+		} `json:"token"`
+	}
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+		if err != nil {
+			return nil, err
+		}
+		if tok := os.Getenv("OS_TOKEN"); tok != "" {
+			req.Header.Set("X-Auth-Token", tok)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("GET %s: %s", source, resp.Status)
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+			return nil, fmt.Errorf("decode %s: %w", source, err)
+		}
+	} else {
+		data, err := os.ReadFile(source)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("decode %s: %w", source, err)
+		}
+	}
+	if doc.Catalog == nil {
+		doc.Catalog = doc.Token.Catalog
+	}
+	return doc.Catalog, nil
+}
+
+// catalogType returns the service node name of a catalog type.
+func catalogType(t string) string {
+	if alias, ok := catalogTypeAliases[t]; ok {
+		return alias
+	}
+	return t
+}
+
+// catalogEdges links every catalog endpoint to the Ingresses whose rules
+// serve its host and to the Service its host names in cluster DNS (see
+// serviceByHost).
+func (r *resolver) catalogEdges(catalog []CatalogEntry) []Edge {
+	ingressHosts := map[string][]string{}
+	for _, ing := range r.ingresses() {
+		for _, rule := range ing.Spec.Rules {
+			h := strings.ToLower(rule.Host)
+			ingressHosts[h] = append(ingressHosts[h], r.node(ing.Namespace, ing.Name, "Ingress"))
+		}
+	}
+	var out []Edge
+	for _, entry := range catalog {
+		from := r.g.AddKey(KeyOf("OpenStackService", "", catalogType(entry.Type)), "")
+		for _, ep := range entry.Endpoints {
+			u, err := url.Parse(ep.URL)
+			if err != nil || u.Hostname() == "" {
+				continue
+			}
+			attrs := map[string]string{"interface": ep.Interface, "url": ep.URL}
+			if ep.Region != "" {
+				attrs["region"] = ep.Region
+			}
+			host := strings.ToLower(u.Hostname())
+			targets := append([]string(nil), ingressHosts[host]...)
+			if svc, ok := r.serviceByHost(host, ""); ok {
+				targets = append(targets, r.node(svc.Namespace, svc.Name, "Service"))
+			}
+			sort.Strings(targets)
+			for _, to := range targets {
+				out = append(out, Edge{From: from, To: to, Kind: Exposes, Attrs: attrs})
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,132 @@
+package collector
+
+import (
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ownerRelations derive Owns edges from metadata.ownerReferences, one
+// relation per collected kind. The edges point from the owner to obj and
+// are exactly what the garbage collector sees, so they cover controllers
+// the collector has no stage for (operators such as rook-ceph or
+// rabbitmq-system) without guessing from label selectors.
+var ownerRelations = []*relation{
+	newOwnerRelation("Deployment", (*resolver).deployments),
+	newOwnerRelation("ReplicaSet", (*resolver).replicaSets),
+	newOwnerRelation("DaemonSet", (*resolver).daemonSets),
+	newOwnerRelation("StatefulSet", (*resolver).statefulSets),
+	newOwnerRelation("ControllerRevision", (*resolver).controllerRevisions),
+	newOwnerRelation("CronJob", (*resolver).cronJobs),
+	newOwnerRelation("Job", (*resolver).jobs),
+	newOwnerRelation("Pod", (*resolver).pods),
+	newOwnerRelation("Service", (*resolver).services),
+	newOwnerRelation("EndpointSlice", (*resolver).endpointSlices),
+	newOwnerRelation("Ingress", (*resolver).ingresses),
+	newOwnerRelation("NetworkPolicy", (*resolver).networkPolicies),
+	newOwnerRelation("PersistentVolumeClaim", (*resolver).pvcs),
+	newOwnerRelation("PersistentVolume", (*resolver).pvs),
+	newOwnerRelation("ConfigMap", (*resolver).configMaps),
+	newOwnerRelation("Secret", (*resolver).secrets),
+	newOwnerRelation("ServiceAccount", (*resolver).serviceAccounts),
+	newOwnerRelation("HorizontalPodAutoscaler", (*resolver).hpas),
+	newOwnerRelation("PodDisruptionBudget", (*resolver).pdbs),
+	newOwnerRelation("Role", (*resolver).roles),
+	newOwnerRelation("ClusterRole", (*resolver).clusterRoles),
+	newOwnerRelation("RoleBinding", (*resolver).roleBindings),
+	newOwnerRelation("ClusterRoleBinding", (*resolver).clusterRoleBindings),
+}
+
+// ownersOf returns the owner relations of kinds.
+func ownersOf(kinds ...string) []*relation {
+	var out []*relation
+	for _, rel := range ownerRelations {
+		for _, k := range kinds {
+			if rel.kind == k {
+				out = append(out, rel)
+			}
+		}
+	}
+	return out
+}
+
+// podOwners is the Pod owner relation limited to pods owned by kinds, so a
+// controller's stage links its own pods without adding every other pod.
+func podOwners(kinds ...string) *relation {
+	pods := ownersOf("Pod")[0]
+	return &relation{
+		name:    "pod-owners-" + strings.ToLower(strings.Join(kinds, "-")),
+		kind:    "Pod",
+		edges:   []EdgeKind{Owns},
+		inbound: true,
+		each: func(r *resolver, fn func(obj metav1.Object)) {
+			pods.each(r, func(obj metav1.Object) {
+				if len(ownerRefsOf(obj, kinds)) > 0 {
+					fn(obj)
+				}
+			})
+		},
+		decode: pods.decode,
+		derive: func(r *resolver, obj metav1.Object) []Edge {
+			return r.ownerEdges("Pod", obj, ownerRefsOf(obj, kinds))
+		},
+	}
+}
+
+// ownerRefsOf returns the ownerReferences of obj to the given kinds.
+func ownerRefsOf(obj metav1.Object, kinds []string) []metav1.OwnerReference {
+	var out []metav1.OwnerReference
+	for _, ref := range obj.GetOwnerReferences() {
+		for _, k := range kinds {
+			if ref.Kind == k {
+				out = append(out, ref)
+				break
+			}
+		}
+	}
+	return out
+}
+
+func newOwnerRelation[T any, P interface {
+	*T
+	metav1.Object
+}](kind string, list func(r *resolver) []T) *relation {
+	rel := newRelation(kind+"-owners", kind, []EdgeKind{Owns}, nil, list,
+		func(r *resolver, obj P) []Edge { return r.owners(kind, obj) })
+	rel.inbound = true
+	return rel
+}
+
+// owners links every ownerReference of obj to obj. The controller
+// reference is marked with controller=true. A claim's reference to the
+// StatefulSet it was templated from is left to claimTemplateRelation.
+func (r *resolver) owners(kind string, obj metav1.Object) []Edge {
+	refs := obj.GetOwnerReferences()
+	if kind == "PersistentVolumeClaim" {
+		var kept []metav1.OwnerReference
+		for _, ref := range refs {
+			if !r.claimTemplateOwner(obj, ref) {
+				kept = append(kept, ref)
+			}
+		}
+		refs = kept
+	}
+	return r.ownerEdges(kind, obj, refs)
+}
+
+func (r *resolver) ownerEdges(kind string, obj metav1.Object, refs []metav1.OwnerReference) []Edge {
+	if len(refs) == 0 {
+		return nil
+	}
+	to := r.node(obj.GetNamespace(), obj.GetName(), kind)
+	out := make([]Edge, 0, len(refs))
+	for _, ref := range refs {
+		from := r.g.AddKey(OwnerKey(obj.GetNamespace(), ref), ref.UID)
+		var attrs map[string]string
+		if ref.Controller != nil && *ref.Controller {
+			attrs = map[string]string{"controller": "true"}
+		}
+		out = append(out, Edge{From: from, To: to, Kind: Owns, Attrs: attrs})
+	}
+	return out
+}
@@ -0,0 +1,200 @@
+package collector
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// annotationPrefixes selects the annotations copied onto nodes. Everything
+// else (last-applied-configuration, config hashes, CNI state) is noise for
+// graph queries and is dropped.
+var annotationPrefixes = []string{
+	"meta.helm.sh/",
+	"app.kubernetes.io/",
+	"deployment.kubernetes.io/",
+	"openstackhelm.openstack.org/",
+	"kubernetes.io/description",
+	"kubernetes.io/service-account.name",
+	"pv.kubernetes.io/",
+	"volume.kubernetes.io/",
+}
+
+// objectProps flattens the queryable parts of obj into string properties:
+//
+//	label.<key>, annotation.<key>, phase, condition.<type>, images,
+//	creationTimestamp, resourceVersion, ownerReferences,
+//	capacity.<resource>, allocatable.<resource>, unschedulable (Node),
+//	request.<resource>, accessModes, storageClassName (PVC, PV),
+//	serviceType (Service), addresses (LoadBalancer Service, Ingress, Gateway),
+//	schedule, suspend, lastScheduleTime (CronJob),
+//	podIP, containerPorts (Pod)
+func objectProps(obj interface{}) map[string]string {
+	u, err := asUnstructured(obj)
+	if err != nil {
+		return nil
+	}
+	props := make(map[string]string)
+
+	for k, v := range u.GetLabels() {
+		props["label."+k] = v
+	}
+	for k, v := range u.GetAnnotations() {
+		for _, p := range annotationPrefixes {
+			if strings.HasPrefix(k, p) {
+				props["annotation."+k] = v
+				break
+			}
+		}
+	}
+
+	if ts := u.GetCreationTimestamp(); !ts.IsZero() {
+		props["creationTimestamp"] = ts.UTC().Format("2006-01-02T15:04:05Z")
+	}
+	if rv := u.GetResourceVersion(); rv != "" {
+		props["resourceVersion"] = rv
+	}
+	if refs := u.GetOwnerReferences(); len(refs) > 0 {
+		owners := make([]string, 0, len(refs))
+		for _, o := range refs {
+			owners = append(owners, o.Kind+"/"+o.Name)
+		}
+		props["ownerReferences"] = strings.Join(owners, ",")
+	}
+
+	if phase, ok, _ := unstructured.NestedString(u.Object, "status", "phase"); ok && phase != "" {
+		props["phase"] = phase
+	}
+	conds, _, _ := unstructured.NestedSlice(u.Object, "status", "conditions")
+	for _, c := range conds {
+		cm, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if t, ok := cm["type"].(string); ok {
+			props["condition."+t] = fmt.Sprint(cm["status"])
+		}
+	}
+
+	// Node(및 PVC)의 용량
+	for _, field := range []string{"capacity", "allocatable"} {
+		res, _, _ := unstructured.NestedStringMap(u.Object, "status", field)
+		for name, q := range res {
+			props[field+"."+name] = q
+		}
+	}
+	// Service 유형 (ExternalName은 endpoint가 없음)
+	if t, ok, _ := unstructured.NestedString(u.Object, "spec", "type"); ok && t != "" {
+		props["serviceType"] = t
+	}
+	// 외부 진입점 주소: Service/Ingress의 LoadBalancer, Gateway의 status.addresses
+	var addrs []string
+	lbs, _, _ := unstructured.NestedSlice(u.Object, "status", "loadBalancer", "ingress")
+	for _, lb := range lbs {
+		lm, _ := lb.(map[string]interface{})
+		for _, f := range []string{"ip", "hostname"} {
+			if v, _, _ := unstructured.NestedString(lm, f); v != "" {
+				addrs = append(addrs, v)
+			}
+		}
+	}
+	gwAddrs, _, _ := unstructured.NestedSlice(u.Object, "status", "addresses")
+	for _, a := range gwAddrs {
+		am, _ := a.(map[string]interface{})
+		if v, _, _ := unstructured.NestedString(am, "value"); v != "" {
+			addrs = append(addrs, v)
+		}
+	}
+	if len(addrs) > 0 {
+		props["addresses"] = strings.Join(addrs, ",")
+	}
+
+	// PVC 요청량, 접근 모드, StorageClass (Pending 클레임 진단용)
+	req, _, _ := unstructured.NestedStringMap(u.Object, "spec", "resources", "requests")
+	for name, q := range req {
+		props["request."+name] = q
+	}
+	modes, ok, _ := unstructured.NestedStringSlice(u.Object, "status", "accessModes")
+	if !ok {
+		modes, _, _ = unstructured.NestedStringSlice(u.Object, "spec", "accessModes")
+	}
+	if len(modes) > 0 {
+		props["accessModes"] = strings.Join(modes, ",")
+	}
+	if sc, ok, _ := unstructured.NestedString(u.Object, "spec", "storageClassName"); ok && sc != "" {
+		props["storageClassName"] = sc
+	}
+	if unsched, ok, _ := unstructured.NestedBool(u.Object, "spec", "unschedulable"); ok {
+		props["unschedulable"] = fmt.Sprint(unsched)
+	}
+
+	// CronJob 스케줄
+	if sched, ok, _ := unstructured.NestedString(u.Object, "spec", "schedule"); ok && sched != "" {
+		props["schedule"] = sched
+		if suspend, ok, _ := unstructured.NestedBool(u.Object, "spec", "suspend"); ok {
+			props["suspend"] = fmt.Sprint(suspend)
+		}
+		if last, ok, _ := unstructured.NestedString(u.Object, "status", "lastScheduleTime"); ok {
+			props["lastScheduleTime"] = last
+		}
+	}
+
+	// Pod 주소와 포트 (NetworkPolicy 평가용): "mysql=3306/TCP,9104/TCP"
+	if ip, ok, _ := unstructured.NestedString(u.Object, "status", "podIP"); ok && ip != "" {
+		props["podIP"] = ip
+	}
+	var ports []string
+	cs, _, _ := unstructured.NestedSlice(u.Object, "spec", "containers")
+	for _, c := range cs {
+		cm, _ := c.(map[string]interface{})
+		ps, _, _ := unstructured.NestedSlice(cm, "ports")
+		for _, p := range ps {
+			pm, _ := p.(map[string]interface{})
+			num, ok, _ := unstructured.NestedInt64(pm, "containerPort")
+			if !ok {
+				continue
+			}
+			proto, _, _ := unstructured.NestedString(pm, "protocol")
+			if proto == "" {
+				proto = "TCP"
+			}
+			port := fmt.Sprintf("%d/%s", num, proto)
+			if name, _, _ := unstructured.NestedString(pm, "name"); name != "" {
+				port = name + "=" + port
+			}
+			ports = append(ports, port)
+		}
+	}
+	if len(ports) > 0 {
+		props["containerPorts"] = strings.Join(ports, ",")
+	}
+
+	// Pod는 spec.containers, 워크로드는 spec.template.spec.containers
+	var images []string
+	for _, path := range [][]string{
+		{"spec", "containers"},
+		{"spec", "template", "spec", "containers"},
+		{"spec", "jobTemplate", "spec", "template", "spec", "containers"},
+	} {
+		cs, _, _ := unstructured.NestedSlice(u.Object, path...)
+		for _, c := range cs {
+			if cm, ok := c.(map[string]interface{}); ok {
+				if img, ok := cm["image"].(string); ok {
+					images = append(images, img)
+				}
+			}
+		}
+	}
+	if len(images) > 0 {
+		props["images"] = strings.Join(images, ",")
+	}
+	return props
+}
+
+func asUnstructured(obj interface{}) (*unstructured.Unstructured, error) {
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		return u, nil
+	}
+	return toUnstructured(obj)
+}
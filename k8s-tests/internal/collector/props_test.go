@@ -0,0 +1,157 @@
+package collector
+
+import (
+	"testing"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestObjectProps(t *testing.T) {
+	created := metav1.NewTime(time.Date(2025, 3, 1, 9, 30, 0, 0, time.FixedZone("KST", 9*3600)))
+	lastRun := metav1.NewTime(time.Date(2025, 3, 2, 0, 0, 0, 0, time.UTC))
+	suspend := true
+	storageClass := "general"
+	isController := true
+
+	cases := []struct {
+		name   string
+		obj    interface{}
+		want   map[string]string
+		absent []string
+	}{
+		{
+			name: "pod",
+			obj: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:         "openstack",
+					Name:              "mariadb-server-0",
+					ResourceVersion:   "4711",
+					CreationTimestamp: created,
+					Labels:            map[string]string{"application": "mariadb", "component": "server"},
+					Annotations: map[string]string{
+						"meta.helm.sh/release-name":                        "mariadb",
+						"kubernetes.io/description":                        "galera node",
+						"kubectl.kubernetes.io/last-applied-configuration": "{}",
+						"checksum/config":                                  "0a1b2c",
+					},
+					OwnerReferences: []metav1.OwnerReference{
+						{APIVersion: "apps/v1", Kind: "StatefulSet", Name: "mariadb-server", UID: "s1", Controller: &isController},
+					},
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name:  "mariadb",
+							Image: "docker.io/openstackhelm/mariadb:latest-ubuntu_focal",
+							Ports: []corev1.ContainerPort{
+								{Name: "mysql", ContainerPort: 3306},
+								{ContainerPort: 4567, Protocol: corev1.ProtocolUDP},
+							},
+						},
+						{Name: "exporter", Image: "prom/mysqld-exporter:v0.15.0"},
+					},
+				},
+				Status: corev1.PodStatus{
+					Phase: corev1.PodRunning,
+					PodIP: "10.244.1.17",
+					Conditions: []corev1.PodCondition{
+						{Type: corev1.PodInitialized, Status: corev1.ConditionTrue},
+						{Type: corev1.PodReady, Status: corev1.ConditionFalse},
+					},
+				},
+			},
+			want: map[string]string{
+				"label.application":                    "mariadb",
+				"label.component":                      "server",
+				"annotation.meta.helm.sh/release-name": "mariadb",
+				"annotation.kubernetes.io/description": "galera node",
+				"creationTimestamp":                    "2025-03-01T00:30:00Z",
+				"resourceVersion":                      "4711",
+				"ownerReferences":                      "StatefulSet/mariadb-server",
+				"phase":                                "Running",
+				"condition.Initialized":                "True",
+				"condition.Ready":                      "False",
+				"podIP":                                "10.244.1.17",
+				"containerPorts":                       "mysql=3306/TCP,4567/UDP",
+				"images":                               "docker.io/openstackhelm/mariadb:latest-ubuntu_focal,prom/mysqld-exporter:v0.15.0",
+			},
+			absent: []string{
+				"annotation.kubectl.kubernetes.io/last-applied-configuration",
+				"annotation.checksum/config",
+				"schedule",
+			},
+		},
+		{
+			name: "pending claim",
+			obj: &corev1.PersistentVolumeClaim{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "openstack", Name: "mysql-data-mariadb-server-0"},
+				Spec: corev1.PersistentVolumeClaimSpec{
+					AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+					StorageClassName: &storageClass,
+					Resources: corev1.VolumeResourceRequirements{
+						Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("5Gi")},
+					},
+				},
+				Status: corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimPending},
+			},
+			want: map[string]string{
+				"phase":            "Pending",
+				"request.storage":  "5Gi",
+				"accessModes":      "ReadWriteOnce",
+				"storageClassName": "general",
+			},
+			absent: []string{"capacity.storage", "ownerReferences", "images"},
+		},
+		{
+			name: "load balancer",
+			obj: &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "openstack", Name: "ingress-nginx"},
+				Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer},
+				Status: corev1.ServiceStatus{LoadBalancer: corev1.LoadBalancerStatus{Ingress: []corev1.LoadBalancerIngress{
+					{IP: "172.24.4.10"},
+					{Hostname: "openstack.example.com"},
+				}}},
+			},
+			want: map[string]string{"serviceType": "LoadBalancer", "addresses": "172.24.4.10,openstack.example.com"},
+		},
+		{
+			name: "cronjob",
+			obj: &batchv1.CronJob{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "openstack", Name: "keystone-fernet-rotate"},
+				Spec: batchv1.CronJobSpec{
+					Schedule: "0 */12 * * *",
+					Suspend:  &suspend,
+					JobTemplate: batchv1.JobTemplateSpec{Spec: batchv1.JobSpec{Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "rotate", Image: "openstackhelm/keystone:2024.1"}}},
+					}}},
+				},
+				Status: batchv1.CronJobStatus{LastScheduleTime: &lastRun},
+			},
+			want: map[string]string{
+				"schedule":         "0 */12 * * *",
+				"suspend":          "true",
+				"lastScheduleTime": "2025-03-02T00:00:00Z",
+				"images":           "openstackhelm/keystone:2024.1",
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			props := objectProps(tc.obj)
+			for k, v := range tc.want {
+				if props[k] != v {
+					t.Errorf("%s = %q, want %q", k, props[k], v)
+				}
+			}
+			for _, k := range tc.absent {
+				if v, ok := props[k]; ok {
+					t.Errorf("%s = %q, want unset", k, v)
+				}
+			}
+		})
+	}
+}
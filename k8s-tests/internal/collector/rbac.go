@@ -0,0 +1,215 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+)
+
+// RBAC edges:
+//
+//	RoleBinding|ClusterRoleBinding -subject-> ServiceAccount|User|Group
+//	RoleBinding|ClusterRoleBinding -grants->  Role|ClusterRole
+//	Role|ClusterRole -permits-> APIResource {verbs, resourceNames}
+//
+// APIResource nodes are keyed by API group and resource ("pods/exec" for
+// subresources, "*" for wildcards); the namespace a permission applies in
+// is the one of the RoleBinding, or every namespace for a
+// ClusterRoleBinding. nonResourceURLs rules are not modelled.
+
+const rbacAPIVersion = rbacv1.GroupName + "/v1"
+
+func deriveRoleBinding(r *resolver, rb *rbacv1.RoleBinding) []Edge {
+	return bindingEdges(r, r.node(rb.Namespace, rb.Name, "RoleBinding"), rb.Namespace, rb.Subjects, rb.RoleRef)
+}
+
+func deriveClusterRoleBinding(r *resolver, crb *rbacv1.ClusterRoleBinding) []Edge {
+	return bindingEdges(r, r.node("", crb.Name, "ClusterRoleBinding"), "", crb.Subjects, crb.RoleRef)
+}
+
+// bindingEdges links a binding in namespace ns ("" for cluster bindings)
+// to its role and subjects. ServiceAccount subjects without a namespace
+// default to the binding's.
+func bindingEdges(r *resolver, from, ns string, subjects []rbacv1.Subject, ref rbacv1.RoleRef) []Edge {
+	out := []Edge{{
+		From: from,
+		To:   r.g.AddKey(RefKey(ns, rbacAPIVersion, ref.Kind, ref.Name), ""),
+		Kind: Grants,
+	}}
+	for _, s := range subjects {
+		var key NodeKey
+		switch s.Kind {
+		case rbacv1.ServiceAccountKind:
+			sns := s.Namespace
+			if sns == "" {
+				sns = ns
+			}
+			key = KeyOf("ServiceAccount", sns, s.Name)
+		case rbacv1.UserKind, rbacv1.GroupKind:
+			key = RefKey("", rbacAPIVersion, s.Kind, s.Name)
+		default:
+			continue
+		}
+		out = append(out, Edge{From: from, To: r.g.AddKey(key, ""), Kind: Subject})
+	}
+	return out
+}
+
+func deriveRoleRules(r *resolver, role *rbacv1.Role) []Edge {
+	return ruleEdges(r, r.node(role.Namespace, role.Name, "Role"), role.Rules)
+}
+
+func deriveClusterRoleRules(r *resolver, cr *rbacv1.ClusterRole) []Edge {
+	return ruleEdges(r, r.node("", cr.Name, "ClusterRole"), cr.Rules)
+}
+
+// ruleEdges emits one Permits edge per API group and resource of each rule.
+func ruleEdges(r *resolver, from string, rules []rbacv1.PolicyRule) []Edge {
+	var out []Edge
+	for _, rule := range rules {
+		attrs := map[string]string{"verbs": strings.Join(rule.Verbs, ",")}
+		if len(rule.ResourceNames) > 0 {
+			attrs["resourceNames"] = strings.Join(rule.ResourceNames, ",")
+		}
+		for _, group := range rule.APIGroups {
+			for _, res := range rule.Resources {
+				to := r.g.AddKey(NodeKey{Group: group, Kind: "APIResource", Name: res}, "")
+				out = append(out, Edge{From: from, To: to, Kind: Permits, Attrs: attrs})
+			}
+		}
+	}
+	return out
+}
+
+// PermissionStages collect what PodPermissions needs from a cluster.
+var PermissionStages = []Stage{ServiceAccountStage, RBACStage}
+
+// Permission is one thing a pod's service account may do against the
+// apiserver, and the chain of objects granting it.
+type Permission struct {
+	Verbs         []string
+	APIGroup      string // "" = core, "*" = every group
+	Resource      string // "pods", "pods/exec", "*"
+	ResourceNames []string
+	Namespace     string // "" = every namespace
+	Subject       string // ServiceAccount or Group node UID the binding names
+	Binding       string // RoleBinding or ClusterRoleBinding node UID
+	Role          string // Role or ClusterRole node UID
+}
+
+// Broad reports whether p is the kind of grant that makes a service account
+// over-privileged: wildcard verbs, resources or API groups, or reading
+// secrets in every namespace.
+func (p Permission) Broad() bool {
+	if p.APIGroup == "*" || p.Resource == "*" || hasString(p.Verbs, "*") {
+		return true
+	}
+	if p.Namespace == "" && p.APIGroup == "" && p.Resource == "secrets" && len(p.ResourceNames) == 0 {
+		for _, v := range []string{"get", "list", "watch"} {
+			if hasString(p.Verbs, v) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (p Permission) String() string {
+	res := p.Resource
+	if p.APIGroup != "" {
+		res += "." + p.APIGroup
+	}
+	if len(p.ResourceNames) > 0 {
+		res += "/" + strings.Join(p.ResourceNames, ",")
+	}
+	scope := "in all namespaces"
+	if p.Namespace != "" {
+		scope = "in namespace " + p.Namespace
+	}
+	return fmt.Sprintf("%s %s %s via %s -> %s", strings.Join(p.Verbs, ","), res, scope, p.Binding, p.Role)
+}
+
+func hasString(list []string, s string) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
+// PodPermissions collects PermissionStages from c and answers for one pod.
+func PodPermissions(ctx context.Context, c Client, ns, pod string) ([]Permission, error) {
+	g := NewGraph()
+	for _, stage := range PermissionStages {
+		if err := stage(ctx, c, g); err != nil {
+			return nil, fmt.Errorf("%s: %w", stageName(stage), err)
+		}
+	}
+	return g.Snapshot().PodPermissions(ns, pod)
+}
+
+// PodPermissions answers "what can this pod do against the apiserver" by
+// following Pod -uses-> ServiceAccount and the RBAC edges from there. The
+// service account also counts as a member of system:serviceaccounts,
+// system:serviceaccounts:<namespace> and system:authenticated.
+func (s *Snapshot) PodPermissions(ns, pod string) ([]Permission, error) {
+	podNode, ok := s.Lookup("Pod", ns, pod)
+	if !ok {
+		return nil, fmt.Errorf("pod %s/%s not found in graph", ns, pod)
+	}
+	var subjects []string
+	for _, e := range s.Incident(podNode.UID) {
+		if e.From != podNode.UID || e.Kind != Uses {
+			continue
+		}
+		if sa, ok := s.Nodes[e.To]; ok && sa.Type == "ServiceAccount" {
+			subjects = append(subjects, sa.UID)
+		}
+	}
+	if len(subjects) == 0 {
+		return nil, fmt.Errorf("pod %s/%s has no service account in graph", ns, pod)
+	}
+	for _, group := range []string{"system:serviceaccounts", "system:serviceaccounts:" + ns, "system:authenticated"} {
+		subjects = append(subjects, RefKey("", rbacAPIVersion, rbacv1.GroupKind, group).ID())
+	}
+
+	var out []Permission
+	for _, subject := range subjects {
+		for _, b := range s.Incident(subject) {
+			if b.Kind != Subject || b.To != subject {
+				continue
+			}
+			binding := s.Nodes[b.From]
+			for _, g := range s.Incident(binding.UID) {
+				if g.Kind != Grants || g.From != binding.UID {
+					continue
+				}
+				for _, p := range s.Incident(g.To) {
+					if p.Kind != Permits || p.From != g.To {
+						continue
+					}
+					res := s.Nodes[p.To]
+					perm := Permission{
+						Verbs:     strings.Split(p.Attrs["verbs"], ","),
+						APIGroup:  res.Group,
+						Resource:  res.Label,
+						Namespace: binding.NS,
+						Subject:   subject,
+						Binding:   binding.UID,
+						Role:      g.To,
+					}
+					if names := p.Attrs["resourceNames"]; names != "" {
+						perm.ResourceNames = strings.Split(names, ",")
+					}
+					out = append(out, perm)
+				}
+			}
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].String() < out[j].String() })
+	return out, nil
+}
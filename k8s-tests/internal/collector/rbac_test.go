@@ -0,0 +1,56 @@
+package collector
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPodPermissions(t *testing.T) {
+	c := loadFake(t, "rbac.yaml")
+	cases := []struct {
+		pod   string
+		want  []string
+		broad bool
+	}{
+		{
+			pod: "openstack/keystone-api-0",
+			want: []string{
+				"get secrets/keystone-etc,keystone-db-user in namespace openstack via RoleBinding.rbac.authorization.k8s.io/openstack/keystone-api -> Role.rbac.authorization.k8s.io/openstack/keystone-api",
+				"get,list namespaces in all namespaces via ClusterRoleBinding.rbac.authorization.k8s.io/namespace-reader -> ClusterRole.rbac.authorization.k8s.io/namespace-reader",
+				"get,list,create pods in namespace openstack via RoleBinding.rbac.authorization.k8s.io/openstack/keystone-api -> Role.rbac.authorization.k8s.io/openstack/keystone-api",
+				"get,list,create pods/exec in namespace openstack via RoleBinding.rbac.authorization.k8s.io/openstack/keystone-api -> Role.rbac.authorization.k8s.io/openstack/keystone-api",
+			},
+		},
+		{
+			pod: "operators/mariadb-operator-0",
+			want: []string{
+				"* *.* in all namespaces via ClusterRoleBinding.rbac.authorization.k8s.io/mariadb-operator -> ClusterRole.rbac.authorization.k8s.io/mariadb-operator",
+				"get,list namespaces in all namespaces via ClusterRoleBinding.rbac.authorization.k8s.io/namespace-reader -> ClusterRole.rbac.authorization.k8s.io/namespace-reader",
+			},
+			broad: true,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.pod, func(t *testing.T) {
+			ns, name, _ := strings.Cut(tc.pod, "/")
+			perms, err := PodPermissions(context.Background(), c, ns, name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			broad := false
+			for _, p := range perms {
+				got = append(got, p.String())
+				broad = broad || p.Broad()
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("permissions:\n%q\nwant:\n%q", got, tc.want)
+			}
+			if broad != tc.broad {
+				t.Fatalf("broad = %v, want %v", broad, tc.broad)
+			}
+		})
+	}
+}
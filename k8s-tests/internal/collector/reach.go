@@ -0,0 +1,380 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// ReachStages collect what Reach needs from a cluster: pods with their
+// labels and ports, the workloads and services that group them, and the
+// NetworkPolicy selects/admits edges.
+var ReachStages = []Stage{WorkloadStage, OwnershipStage, NetpolStage}
+
+// ReachQuery asks whether From may connect to To. Both are references in
+// the form kind/namespace/name, where kind is a pod, a service or a
+// workload (deploy, sts, ds, rs, job, cronjob). Port is a destination pod
+// port; zero means any port that both the egress and the ingress policies
+// admit. Protocol defaults to TCP.
+type ReachQuery struct {
+	From, To string
+	Protocol corev1.Protocol
+	Port     int32
+}
+
+// RuleRef names one rule of a NetworkPolicy.
+type RuleRef struct {
+	Policy    string // namespace/name
+	Direction string // ingress or egress
+	Rule      int
+}
+
+func (r RuleRef) String() string {
+	return fmt.Sprintf("%s %s rule %d", r.Policy, r.Direction, r.Rule)
+}
+
+// DirectionVerdict explains one side of a connection: the egress of the
+// source or the ingress of the destination. A pod no policy isolates in
+// that direction is allowed; an isolated pod needs a rule of one of its
+// policies to admit the peer and port.
+type DirectionVerdict struct {
+	Isolated  bool
+	Policies  []string  // policies isolating the pod in this direction
+	AllowedBy []RuleRef // rules admitting the connection
+
+	ports []portRange // ports admitted in this direction
+}
+
+func (v DirectionVerdict) Allowed() bool {
+	return !v.Isolated || len(v.AllowedBy) > 0
+}
+
+// PairVerdict is the answer for one source pod and one destination pod.
+// Self marks a pod paired with itself: that traffic stays on loopback,
+// which NetworkPolicy cannot block, so neither direction is isolated.
+// Port is the port the verdicts are for. A query for any port is answered
+// for the lowest port both directions admit, and Port stays zero if they
+// admit none in common.
+type PairVerdict struct {
+	From, To string // pod node UIDs
+	Self     bool
+	Port     int32
+	Egress   DirectionVerdict
+	Ingress  DirectionVerdict
+}
+
+func (v PairVerdict) Allowed() bool {
+	return v.Self || v.Port != 0 && v.Egress.Allowed() && v.Ingress.Allowed()
+}
+
+// ReachResult holds a verdict for every pod pair behind a query.
+type ReachResult struct {
+	Query ReachQuery
+	Pairs []PairVerdict
+}
+
+// Allowed reports whether every pod pair may connect.
+func (r *ReachResult) Allowed() bool {
+	for _, p := range r.Pairs {
+		if !p.Allowed() {
+			return false
+		}
+	}
+	return len(r.Pairs) > 0
+}
+
+// Reach collects ReachStages from c and answers q against the result.
+func Reach(ctx context.Context, c Client, q ReachQuery) (*ReachResult, error) {
+	g := NewGraph()
+	for _, stage := range ReachStages {
+		if err := stage(ctx, c, g); err != nil {
+			return nil, fmt.Errorf("%s: %w", stageName(stage), err)
+		}
+	}
+	return g.Snapshot().Reach(q)
+}
+
+// Reach answers q from the NetworkPolicy edges in s, so it works on a live
+// graph and on one loaded from the CSV exports alike. Pods are matched
+// against the admits-from/admits-to peers by namespace, label and IP, and
+// named ports are resolved against the destination's container ports.
+func (s *Snapshot) Reach(q ReachQuery) (*ReachResult, error) {
+	if q.Protocol == "" {
+		q.Protocol = corev1.ProtocolTCP
+	}
+	srcs, err := s.reachPods(q.From)
+	if err != nil {
+		return nil, err
+	}
+	dsts, err := s.reachPods(q.To)
+	if err != nil {
+		return nil, err
+	}
+	res := &ReachResult{Query: q}
+	for _, src := range srcs {
+		for _, dst := range dsts {
+			if src.UID == dst.UID {
+				res.Pairs = append(res.Pairs, PairVerdict{From: src.UID, To: dst.UID, Self: true, Port: q.Port})
+				continue
+			}
+			pv := PairVerdict{
+				From:    src.UID,
+				To:      dst.UID,
+				Port:    q.Port,
+				Egress:  s.verdict(src, src, dst, AdmitsTo, q),
+				Ingress: s.verdict(dst, src, dst, AdmitsFrom, q),
+			}
+			// 두 방향이 서로 다른 포트만 허용할 수 있으므로 공통 포트로 다시 판정
+			if q.Port == 0 {
+				if pv.Port = commonPort(pv.Egress.ports, pv.Ingress.ports); pv.Port != 0 {
+					pq := q
+					pq.Port = pv.Port
+					pv.Egress = s.verdict(src, src, dst, AdmitsTo, pq)
+					pv.Ingress = s.verdict(dst, src, dst, AdmitsFrom, pq)
+				}
+			}
+			res.Pairs = append(res.Pairs, pv)
+		}
+	}
+	return res, nil
+}
+
+// reachAliases maps the kind spellings ReachQuery accepts to kinds.
+var reachAliases = map[string]string{
+	"pod": "Pod", "pods": "Pod", "po": "Pod",
+	"service": "Service", "services": "Service", "svc": "Service",
+	"deployment": "Deployment", "deployments": "Deployment", "deploy": "Deployment",
+	"statefulset": "StatefulSet", "statefulsets": "StatefulSet", "sts": "StatefulSet",
+	"daemonset": "DaemonSet", "daemonsets": "DaemonSet", "ds": "DaemonSet",
+	"replicaset": "ReplicaSet", "replicasets": "ReplicaSet", "rs": "ReplicaSet",
+	"job": "Job", "jobs": "Job",
+	"cronjob": "CronJob", "cronjobs": "CronJob", "cj": "CronJob",
+}
+
+// ParseRef parses a kind/namespace/name reference.
+func ParseRef(ref string) (NodeKey, error) {
+	parts := strings.Split(ref, "/")
+	if len(parts) != 3 || parts[1] == "" || parts[2] == "" {
+		return NodeKey{}, fmt.Errorf("reference %q: want kind/namespace/name", ref)
+	}
+	kind, ok := reachAliases[strings.ToLower(parts[0])]
+	if !ok {
+		return NodeKey{}, fmt.Errorf("reference %q: unsupported kind %q", ref, parts[0])
+	}
+	return KeyOf(kind, parts[1], parts[2]), nil
+}
+
+// reachPods resolves ref to pods: a pod itself, the pods a service routes
+// to, or the pods a workload owns directly or through ReplicaSets/Jobs.
+func (s *Snapshot) reachPods(ref string) ([]Node, error) {
+	key, err := ParseRef(ref)
+	if err != nil {
+		return nil, err
+	}
+	root, ok := s.Nodes[key.ID()]
+	if !ok {
+		return nil, fmt.Errorf("%s not found in graph", ref)
+	}
+	follow := Owns
+	if key.Kind == "Service" {
+		follow = Routes
+	}
+	var pods []Node
+	seen := map[string]bool{root.UID: true}
+	queue := []Node{root}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		if n.Type == "Pod" {
+			pods = append(pods, n)
+			continue
+		}
+		for _, e := range s.Incident(n.UID) {
+			if e.From != n.UID || e.Kind != follow || seen[e.To] {
+				continue
+			}
+			seen[e.To] = true
+			if child, ok := s.Nodes[e.To]; ok {
+				queue = append(queue, child)
+			}
+		}
+	}
+	if len(pods) == 0 {
+		return nil, fmt.Errorf("%s has no pods in graph", ref)
+	}
+	sort.Slice(pods, func(i, j int) bool { return pods[i].UID < pods[j].UID })
+	return pods, nil
+}
+
+// verdict evaluates the policies that select subject in the direction of
+// admit (AdmitsTo for egress, AdmitsFrom for ingress) against the
+// connection src → dst.
+func (s *Snapshot) verdict(subject, src, dst Node, admit EdgeKind, q ReachQuery) DirectionVerdict {
+	dir, peer := "ingress", src
+	if admit == AdmitsTo {
+		dir, peer = "egress", dst
+	}
+	var v DirectionVerdict
+	for _, sel := range s.Incident(subject.UID) {
+		if sel.Kind != Selects || sel.To != subject.UID ||
+			!strings.Contains(strings.ToLower(sel.Attrs["policyTypes"]), dir) {
+			continue
+		}
+		policy, ok := s.Nodes[sel.From]
+		if !ok {
+			continue
+		}
+		v.Isolated = true
+		v.Policies = append(v.Policies, policy.NS+"/"+policy.Label)
+
+		admitted := map[int]bool{}
+		for _, e := range s.Incident(policy.UID) {
+			if e.Kind != admit || e.From != policy.UID {
+				continue
+			}
+			rule, err := strconv.Atoi(e.Attrs["rule"])
+			if err != nil || admitted[rule] {
+				continue
+			}
+			if !s.peerAdmits(e, peer) {
+				continue
+			}
+			if ports := admittedPorts(e.Attrs["ports"], dst, q.Protocol); portsAdmit(ports, q.Port) {
+				admitted[rule] = true
+				v.AllowedBy = append(v.AllowedBy, RuleRef{Policy: policy.NS + "/" + policy.Label, Direction: dir, Rule: rule})
+				v.ports = append(v.ports, ports...)
+			}
+		}
+	}
+	if !v.Isolated {
+		v.ports = []portRange{anyPort}
+	}
+	sort.Strings(v.Policies)
+	sort.Slice(v.AllowedBy, func(i, j int) bool { return v.AllowedBy[i].String() < v.AllowedBy[j].String() })
+	return v
+}
+
+// peerAdmits reports whether the peer group at the end of e contains pod.
+func (s *Snapshot) peerAdmits(e Edge, pod Node) bool {
+	target, ok := s.Nodes[e.To]
+	if !ok {
+		return false
+	}
+	switch target.Type {
+	case "Namespace":
+		if pod.NS != target.Label {
+			return false
+		}
+		sel, err := labels.Parse(e.Attrs["podSelector"])
+		return err == nil && sel.Matches(podLabels(pod))
+	case "IPBlock":
+		var except []string
+		if ex := e.Attrs["except"]; ex != "" {
+			except = strings.Split(ex, ",")
+		}
+		return ipBlockContains(target.Label, except, pod.Props["podIP"])
+	}
+	return false
+}
+
+func podLabels(pod Node) labels.Set {
+	l := labels.Set{}
+	for k, v := range pod.Props {
+		if name, ok := strings.CutPrefix(k, "label."); ok {
+			l[name] = v
+		}
+	}
+	return l
+}
+
+// portRange is an inclusive range of destination ports.
+type portRange struct{ lo, hi int32 }
+
+var anyPort = portRange{1, 65535}
+
+// admittedPorts returns the proto ports admitted by rendered rule ports (see
+// policyPorts). Named ports resolve through the containerPorts property of
+// dst. Empty ports admit everything.
+func admittedPorts(ports string, dst Node, proto corev1.Protocol) []portRange {
+	if ports == "" {
+		return []portRange{anyPort}
+	}
+	var out []portRange
+	for _, p := range strings.Split(ports, ",") {
+		pproto, spec, ok := strings.Cut(p, "/")
+		if !ok || corev1.Protocol(pproto) != proto {
+			continue
+		}
+		if spec == "*" {
+			out = append(out, anyPort)
+			continue
+		}
+		lo, hi, isRange := strings.Cut(spec, "-")
+		from, err := strconv.Atoi(lo)
+		if err != nil {
+			// named port
+			if num, ok := containerPort(dst, spec, proto); ok {
+				out = append(out, portRange{num, num})
+			}
+			continue
+		}
+		to := from
+		if isRange {
+			if to, err = strconv.Atoi(hi); err != nil {
+				continue
+			}
+		}
+		out = append(out, portRange{int32(from), int32(to)})
+	}
+	return out
+}
+
+// portsAdmit reports whether ports contain port; a zero port asks for any
+// admitted one.
+func portsAdmit(ports []portRange, port int32) bool {
+	for _, r := range ports {
+		if port == 0 || (port >= r.lo && port <= r.hi) {
+			return true
+		}
+	}
+	return false
+}
+
+// commonPort returns the lowest port in both a and b, or zero if there is
+// none.
+func commonPort(a, b []portRange) int32 {
+	var port int32
+	for _, x := range a {
+		for _, y := range b {
+			lo, hi := max(x.lo, y.lo), min(x.hi, y.hi)
+			if lo <= hi && (port == 0 || lo < port) {
+				port = lo
+			}
+		}
+	}
+	return port
+}
+
+// containerPort looks a named port up in the containerPorts property
+// ("mysql=3306/TCP,9104/TCP").
+func containerPort(pod Node, name string, proto corev1.Protocol) (int32, bool) {
+	for _, p := range strings.Split(pod.Props["containerPorts"], ",") {
+		n, rest, ok := strings.Cut(p, "=")
+		if !ok || n != name {
+			continue
+		}
+		num, pproto, _ := strings.Cut(rest, "/")
+		if corev1.Protocol(pproto) != proto {
+			continue
+		}
+		if v, err := strconv.Atoi(num); err == nil {
+			return int32(v), true
+		}
+	}
+	return 0, false
+}
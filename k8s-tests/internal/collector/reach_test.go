@@ -0,0 +1,214 @@
+package collector
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/kaist2025/k8s-e2e-tests/internal/k8sclient"
+)
+
+func TestReach(t *testing.T) {
+	c := loadFake(t, "netpol.yaml")
+	cases := []struct {
+		name      string
+		q         ReachQuery
+		allowed   bool
+		allowedBy []string // rules admitting the ingress side of the first pair
+	}{
+		{
+			name:      "ingress and egress both admit",
+			q:         ReachQuery{From: "pod/openstack/keystone-api-0", To: "pod/openstack/mariadb-server-0", Port: 3306},
+			allowed:   true,
+			allowedBy: []string{"openstack/mariadb-allow-keystone ingress rule 0"},
+		},
+		{
+			name: "wrong port",
+			q:    ReachQuery{From: "pod/openstack/keystone-api-0", To: "pod/openstack/mariadb-server-0", Port: 3307},
+		},
+		{
+			name: "peer not admitted",
+			q:    ReachQuery{From: "pod/openstack/nova-api-0", To: "svc/openstack/mariadb", Port: 3306},
+		},
+		{
+			name: "same labels in another namespace",
+			q:    ReachQuery{From: "pod/tenant/keystone-api-0", To: "sts/openstack/mariadb-server", Port: 3306},
+		},
+		{
+			name:      "namespace selector and named port",
+			q:         ReachQuery{From: "pod/monitoring/prometheus-0", To: "sts/openstack/mariadb-server", Port: 9104},
+			allowed:   true,
+			allowedBy: []string{"openstack/mariadb-allow-metrics ingress rule 0"},
+		},
+		{
+			name: "egress isolation",
+			q:    ReachQuery{From: "pod/openstack/keystone-api-0", To: "pod/openstack/nova-api-0", Port: 8774},
+		},
+		{
+			name:    "egress to dns",
+			q:       ReachQuery{From: "pod/openstack/keystone-api-0", To: "pod/kube-system/coredns-0", Protocol: corev1.ProtocolUDP, Port: 53},
+			allowed: true,
+		},
+		{
+			name:      "ipBlock",
+			q:         ReachQuery{From: "pod/openstack/nova-api-0", To: "pod/openstack/keystone-api-0", Port: 5000},
+			allowed:   true,
+			allowedBy: []string{"openstack/keystone-allow-external ingress rule 0"},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := Reach(context.Background(), c, tc.q)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(res.Pairs) == 0 {
+				t.Fatal("no pod pairs")
+			}
+			if res.Allowed() != tc.allowed {
+				t.Fatalf("allowed = %v, want %v: %+v", res.Allowed(), tc.allowed, res.Pairs)
+			}
+			if tc.allowedBy != nil {
+				var got []string
+				for _, r := range res.Pairs[0].Ingress.AllowedBy {
+					got = append(got, r.String())
+				}
+				if !reflect.DeepEqual(got, tc.allowedBy) {
+					t.Fatalf("allowed by %v, want %v", got, tc.allowedBy)
+				}
+			}
+		})
+	}
+}
+
+// Snapshot.Reach works from the graph edges rather than the policies
+// themselves; it must give the same answers as PolicyEvaluator for every
+// pod-to-pod check.
+func TestReachAgreesWithPolicyEvaluator(t *testing.T) {
+	cases, namespaces := policyCases()
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			objs, err := k8sclient.DecodeManifest([]byte(tc.policies))
+			if err != nil {
+				t.Fatal(err)
+			}
+			pods := map[string]*corev1.Pod{}
+			for _, ck := range tc.checks {
+				for _, ep := range []Endpoint{ck.src, ck.dst} {
+					if ep.Pod != nil {
+						pods[ep.Pod.Namespace+"/"+ep.Pod.Name] = ep.Pod
+					}
+				}
+			}
+			for _, p := range pods {
+				objs = append(objs, p.DeepCopy())
+			}
+			for i := range namespaces {
+				objs = append(objs, namespaces[i].DeepCopy())
+			}
+			objs = append(objs, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}})
+			c, err := k8sclient.NewFake(objs...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, ck := range tc.checks {
+				if ck.src.Pod == nil || ck.dst.Pod == nil {
+					continue
+				}
+				q := ReachQuery{
+					From:     "pod/" + ck.src.Pod.Namespace + "/" + ck.src.Pod.Name,
+					To:       "pod/" + ck.dst.Pod.Namespace + "/" + ck.dst.Pod.Name,
+					Protocol: ck.proto,
+					Port:     ck.port,
+				}
+				res, err := Reach(context.Background(), c, q)
+				if err != nil {
+					t.Fatalf("%s: %v", ck.name, err)
+				}
+				if res.Allowed() != ck.want {
+					t.Errorf("%s: %s/%d allowed = %v, want %v: %+v", ck.name, ck.proto, ck.port, res.Allowed(), ck.want, res.Pairs)
+				}
+			}
+		})
+	}
+}
+
+// A pod connecting to itself goes over loopback, which NetworkPolicy
+// cannot block.
+func TestReachSamePod(t *testing.T) {
+	c := loadFake(t, "netpol.yaml")
+	res, err := Reach(context.Background(), c, ReachQuery{From: "pod/openstack/mariadb-server-0", To: "sts/openstack/mariadb-server", Port: 3306})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Pairs) != 1 || !res.Pairs[0].Self || !res.Allowed() {
+		t.Fatalf("pairs = %+v, want one allowed self pair", res.Pairs)
+	}
+}
+
+// Without a port the two directions must admit a port in common: egress to
+// DNS only and ingress on 8080 only leave no port to connect on.
+func TestReachAnyPortNeedsCommonPort(t *testing.T) {
+	c, err := k8sclient.NewFakeFromYAML([]byte(`
+apiVersion: v1
+kind: Pod
+metadata: {namespace: openstack, name: horizon-0, labels: {application: horizon}}
+status: {podIP: 10.244.1.10}
+---
+apiVersion: v1
+kind: Pod
+metadata: {namespace: openstack, name: placement-0, labels: {application: placement}}
+spec:
+  containers:
+  - name: placement
+    ports: [{name: http, containerPort: 8080}]
+status: {podIP: 10.244.1.11}
+---
+apiVersion: v1
+kind: Pod
+metadata: {namespace: openstack, name: keystone-0, labels: {application: keystone}}
+status: {podIP: 10.244.1.12}
+---
+apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata: {namespace: openstack, name: horizon-egress}
+spec:
+  podSelector: {matchLabels: {application: horizon}}
+  policyTypes: [Egress]
+  egress:
+  - ports: [{protocol: UDP, port: 53}, {protocol: TCP, port: 5432}]
+---
+apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata: {namespace: openstack, name: placement-ingress}
+spec:
+  podSelector: {matchLabels: {application: placement}}
+  policyTypes: [Ingress]
+  ingress:
+  - ports: [{protocol: TCP, port: http}, {protocol: UDP, port: 8080}]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, proto := range []corev1.Protocol{corev1.ProtocolTCP, corev1.ProtocolUDP} {
+		res, err := Reach(context.Background(), c, ReachQuery{From: "pod/openstack/horizon-0", To: "pod/openstack/placement-0", Protocol: proto})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Allowed() {
+			t.Errorf("%s/*: allowed on port %d: %+v", proto, res.Pairs[0].Port, res.Pairs)
+		}
+	}
+
+	// 공통 포트가 있으면 그 포트로 판정
+	res, err := Reach(context.Background(), c, ReachQuery{From: "pod/openstack/horizon-0", To: "pod/openstack/keystone-0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Allowed() || res.Pairs[0].Port != 5432 {
+		t.Fatalf("pairs = %+v, want allowed on port 5432", res.Pairs)
+	}
+}
@@ -0,0 +1,695 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	discv1 "k8s.io/api/discovery/v1"
+	netv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// relation derives one family of edges from objects of a single kind. Batch
+// stages run a relation over every listed object; informer events run every
+// relation that starts at, or can point at, the changed object (see Relink).
+type relation struct {
+	name    string
+	kind    string     // kind of the objects the edges are derived from
+	edges   []EdgeKind // edge kinds the relation produces
+	targets []string   // kinds the derived edges can point at
+
+	each   func(r *resolver, fn func(obj metav1.Object))
+	derive func(r *resolver, obj metav1.Object) []Edge
+	decode func(u *unstructured.Unstructured) (metav1.Object, error)
+
+	// owned selects the existing edges derive produced for obj. When nil,
+	// those are the edges of the relation's kinds that start at obj (end at
+	// it when inbound). Relations with an owned func may own edges not
+	// incident to obj.
+	owned   func(e Edge, obj metav1.Object) bool
+	inbound bool
+
+	// scope returns the nodes the edges selected by owned start or end at,
+	// besides obj itself. Relink only looks at edges incident to those.
+	scope func(r *resolver, obj metav1.Object) []string
+
+	// near reports whether src, an object of the relation's kind, can
+	// derive edges touching target, an object of the given target kind.
+	// Relink re-derives only those sources; nil means every source can.
+	near func(kind string, target, src metav1.Object) bool
+
+	// ends returns the nodes whose edges an event on target, an object of
+	// the given target kind, can change. Nil means target's own node; a
+	// target may stand for other nodes, e.g. an EndpointSlice for its
+	// Service.
+	ends func(kind string, target metav1.Object) []string
+}
+
+func newRelation[T any, P interface {
+	*T
+	metav1.Object
+}](name, kind string, edges []EdgeKind, targets []string,
+	list func(r *resolver) []T, derive func(r *resolver, obj P) []Edge) *relation {
+	return &relation{
+		name:    name,
+		kind:    kind,
+		edges:   edges,
+		targets: targets,
+		each: func(r *resolver, fn func(metav1.Object)) {
+			items := list(r)
+			for i := range items {
+				fn(P(&items[i]))
+			}
+		},
+		derive: func(r *resolver, obj metav1.Object) []Edge {
+			return derive(r, obj.(P))
+		},
+		decode: func(u *unstructured.Unstructured) (metav1.Object, error) {
+			var obj P = new(T)
+			err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, obj)
+			return obj, err
+		},
+	}
+}
+
+// ownedBy returns the predicate for the edges rel derived from obj.
+func (rel *relation) ownedBy(uid string, obj metav1.Object) func(Edge) bool {
+	if rel.owned != nil {
+		return func(e Edge) bool { return rel.owned(e, obj) }
+	}
+	if rel.inbound {
+		return func(e Edge) bool { return e.To == uid && hasEdgeKind(rel.edges, e.Kind) }
+	}
+	return func(e Edge) bool { return e.From == uid && hasEdgeKind(rel.edges, e.Kind) }
+}
+
+// namespaced marks rel as pointing only at targets in the source's own
+// namespace.
+func namespaced(rel *relation) *relation {
+	rel.near = func(_ string, target, src metav1.Object) bool {
+		return target.GetNamespace() == src.GetNamespace()
+	}
+	return rel
+}
+
+func (rel *relation) targetsKind(kind string) bool {
+	for _, k := range rel.targets {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+func hasEdgeKind(kinds []EdgeKind, k EdgeKind) bool {
+	for _, x := range kinds {
+		if x == k {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	servicePods = namespaced(newRelation("service-pods", "Service",
+		[]EdgeKind{Routes}, []string{"Pod"}, (*resolver).services, deriveServicePods))
+	ingressServices = namespaced(newRelation("ingress-services", "Ingress",
+		[]EdgeKind{Routes}, []string{"Service"}, (*resolver).ingresses, deriveIngressServices))
+	ingressClassRefs = newRelation("ingress-class", "Ingress",
+		[]EdgeKind{Uses}, []string{"IngressClass"}, (*resolver).ingresses, deriveIngressClass)
+	ingressTLS = namespaced(newRelation("ingress-tls", "Ingress",
+		[]EdgeKind{Reads}, []string{"Secret"}, (*resolver).ingresses, deriveIngressTLS))
+	ingressClassPods = newRelation("ingressclass-pods", "IngressClass",
+		[]EdgeKind{ServedBy}, []string{"Pod"}, (*resolver).ingressClasses, deriveIngressClassPods)
+	serviceSlices     = serviceSliceRelation()
+	endpointSlicePods = newRelation("endpointslice-pods", "EndpointSlice",
+		[]EdgeKind{Targets}, []string{"Pod"}, (*resolver).endpointSlices, deriveEndpointSlicePods)
+	pvcVolumes = newRelation("pvc-pv", "PersistentVolumeClaim",
+		[]EdgeKind{Binds}, []string{"PersistentVolume"}, (*resolver).pvcs, derivePVCVolume)
+	pvcStorageClasses = newRelation("pvc-storageclass", "PersistentVolumeClaim",
+		[]EdgeKind{Uses}, []string{"StorageClass"}, (*resolver).pvcs, derivePVCStorageClass)
+	pvStorageClasses = newRelation("pv-storageclass", "PersistentVolume",
+		[]EdgeKind{Uses}, []string{"StorageClass"}, (*resolver).pvs, derivePVStorageClass)
+	podClaims = namespaced(newRelation("pod-pvc", "Pod",
+		[]EdgeKind{Mounts}, []string{"PersistentVolumeClaim"}, (*resolver).pods, derivePodClaims))
+	claimTemplates    = claimTemplateRelation()
+	volumeAttachments = newRelation("volumeattachment", "VolumeAttachment",
+		[]EdgeKind{Attaches, AttachedTo}, []string{"PersistentVolume", "Node"}, (*resolver).volumeAttachments, deriveVolumeAttachment)
+	netpolSelects = namespaced(newRelation("networkpolicy-selects", "NetworkPolicy",
+		[]EdgeKind{Selects}, []string{"Pod"}, (*resolver).networkPolicies, deriveNetpolSelects))
+	netpolPeers = newRelation("networkpolicy-peers", "NetworkPolicy",
+		[]EdgeKind{AdmitsFrom, AdmitsTo}, []string{"Namespace"}, (*resolver).networkPolicies, deriveNetpolPeers)
+	podConfigs = namespaced(newRelation("pod-config", "Pod",
+		[]EdgeKind{Reads, Mounts}, []string{"ConfigMap", "Secret", "SecretProviderClass"}, (*resolver).pods, derivePodConfig))
+	podServiceAccounts = namespaced(newRelation("pod-serviceaccount", "Pod",
+		[]EdgeKind{Uses}, []string{"ServiceAccount"}, (*resolver).pods, derivePodServiceAccount))
+	hpaTargets = newRelation("hpa-target", "HorizontalPodAutoscaler",
+		[]EdgeKind{Scales}, nil, (*resolver).hpas, deriveHPATarget)
+	pdbPods = namespaced(newRelation("pdb-pods", "PodDisruptionBudget",
+		[]EdgeKind{Protects}, []string{"Pod"}, (*resolver).pdbs, derivePDBPods))
+	podWaits     = podWaitsRelation()
+	podNodes     = podNodeRelation()
+	nodeTopology = newRelation("node-topology", "Node",
+		[]EdgeKind{LocatedIn}, nil, (*resolver).nodes, deriveNodeTopology)
+	roleBindings = newRelation("rolebinding", "RoleBinding",
+		[]EdgeKind{Subject, Grants}, []string{"ServiceAccount", "Role", "ClusterRole"}, (*resolver).roleBindings, deriveRoleBinding)
+	clusterRoleBindings = newRelation("clusterrolebinding", "ClusterRoleBinding",
+		[]EdgeKind{Subject, Grants}, []string{"ServiceAccount", "ClusterRole"}, (*resolver).clusterRoleBindings, deriveClusterRoleBinding)
+	roleRules = newRelation("role-rules", "Role",
+		[]EdgeKind{Permits}, nil, (*resolver).roles, deriveRoleRules)
+	clusterRoleRules = newRelation("clusterrole-rules", "ClusterRole",
+		[]EdgeKind{Permits}, nil, (*resolver).clusterRoles, deriveClusterRoleRules)
+)
+
+// relations is every relation the event path keeps up to date.
+var relations = append(append(append(append([]*relation{
+	servicePods,
+	ingressServices,
+	ingressClassRefs,
+	ingressTLS,
+	ingressClassPods,
+	gatewayClassPods,
+	gatewayRefs,
+	httpRouteParents,
+	httpRouteBackends,
+	grpcRouteParents,
+	grpcRouteBackends,
+	serviceSlices,
+	endpointSlicePods,
+	pvcVolumes,
+	pvcStorageClasses,
+	pvStorageClasses,
+	podClaims,
+	claimTemplates,
+	volumeAttachments,
+	netpolSelects,
+	netpolPeers,
+	podConfigs,
+	podServiceAccounts,
+	hpaTargets,
+	pdbPods,
+	podWaits,
+	podNodes,
+	nodeTopology,
+	roleBindings,
+	clusterRoleBindings,
+	roleRules,
+	clusterRoleRules,
+}, openstackRelations...), configDependencies...), ownerRelations...), partOfRelations...)
+
+// ───────────────────────── resolver ────────────────────────────────────────
+
+// resolver answers the lookups relations need and lists each kind at most
+// once. A batch stage shares one resolver across all of its objects, and so
+// does a batch of queued informer events (see Collector.Enqueue); the next
+// batch gets a fresh one so it sees the current cluster state.
+type resolver struct {
+	ctx   context.Context
+	c     Client
+	g     *Graph
+	cache map[string]any
+	errs  []error
+}
+
+func newResolver(ctx context.Context, c Client, g *Graph) *resolver {
+	return &resolver{ctx: ctx, c: c, g: g, cache: make(map[string]any)}
+}
+
+func cached[V any](r *resolver, key string, load func() V) V {
+	if v, ok := r.cache[key]; ok {
+		return v.(V)
+	}
+	v := load()
+	r.cache[key] = v
+	return v
+}
+
+// listing is a cached list result. The error is kept with the items so every
+// relink sharing the resolver reports it, not only the first.
+type listing[T any] struct {
+	items []T
+	err   error
+}
+
+// listed lists kind at most once. A failed list is recorded as a ListError
+// and yields no objects, so the relations using it derive fewer edges.
+func listed[T any](r *resolver, kind string, list func(context.Context) ([]T, error)) []T {
+	l := cached(r, kind, func() listing[T] {
+		items, err := list(r.ctx)
+		if err != nil {
+			return listing[T]{err: &ListError{Kind: kind, Err: err}}
+		}
+		return listing[T]{items: items}
+	})
+	if l.err != nil && !slices.Contains(r.errs, l.err) {
+		r.errs = append(r.errs, l.err)
+	}
+	return l.items
+}
+
+// err joins the list failures seen so far.
+func (r *resolver) err() error {
+	return errors.Join(r.errs...)
+}
+
+// run adds the objects and derived edges of rels to the graph and reports
+// the kinds that could not be listed.
+func (r *resolver) run(rels ...*relation) error {
+	for _, rel := range rels {
+		rel.each(r, func(obj metav1.Object) {
+			r.g.AddObject(rel.kind, obj)
+			for _, e := range rel.derive(r, obj) {
+				r.g.AddEdgeAttrs(e.From, e.To, e.Kind, e.Attrs)
+			}
+		})
+	}
+	return r.err()
+}
+
+// node returns the UID of kind/ns/name, adding a reference node if needed.
+func (r *resolver) node(ns, name, kind string) string {
+	return r.g.AddNode(ns, name, kind)
+}
+
+func (r *resolver) pods() []corev1.Pod {
+	return listed(r, "Pod", r.c.Pods)
+}
+func (r *resolver) deployments() []appsv1.Deployment {
+	return listed(r, "Deployment", r.c.Deployments)
+}
+func (r *resolver) replicaSets() []appsv1.ReplicaSet {
+	return listed(r, "ReplicaSet", r.c.ReplicaSets)
+}
+func (r *resolver) daemonSets() []appsv1.DaemonSet {
+	return listed(r, "DaemonSet", r.c.DaemonSets)
+}
+func (r *resolver) statefulSets() []appsv1.StatefulSet {
+	return listed(r, "StatefulSet", r.c.StatefulSets)
+}
+func (r *resolver) controllerRevisions() []appsv1.ControllerRevision {
+	return listed(r, "ControllerRevision", r.c.ControllerRevisions)
+}
+func (r *resolver) jobs() []batchv1.Job {
+	return listed(r, "Job", r.c.Jobs)
+}
+func (r *resolver) cronJobs() []batchv1.CronJob {
+	return listed(r, "CronJob", r.c.CronJobs)
+}
+func (r *resolver) services() []corev1.Service {
+	return listed(r, "Service", r.c.Services)
+}
+func (r *resolver) ingresses() []netv1.Ingress {
+	return listed(r, "Ingress", r.c.Ingresses)
+}
+func (r *resolver) ingressClasses() []netv1.IngressClass {
+	return listed(r, "IngressClass", r.c.IngressClasses)
+}
+func (r *resolver) endpointSlices() []discv1.EndpointSlice {
+	return listed(r, "EndpointSlice", r.c.EndpointSlices)
+}
+func (r *resolver) pvcs() []corev1.PersistentVolumeClaim {
+	return listed(r, "PersistentVolumeClaim", r.c.PVCs)
+}
+func (r *resolver) pvs() []corev1.PersistentVolume {
+	return listed(r, "PersistentVolume", r.c.PVs)
+}
+func (r *resolver) volumeAttachments() []storagev1.VolumeAttachment {
+	return listed(r, "VolumeAttachment", r.c.VolumeAttachments)
+}
+func (r *resolver) networkPolicies() []netv1.NetworkPolicy {
+	return listed(r, "NetworkPolicy", r.c.NetworkPolicies)
+}
+func (r *resolver) hpas() []autoscalingv2.HorizontalPodAutoscaler {
+	return listed(r, "HorizontalPodAutoscaler", r.c.HorizontalPodAutoscalers)
+}
+func (r *resolver) pdbs() []policyv1.PodDisruptionBudget {
+	return listed(r, "PodDisruptionBudget", r.c.PodDisruptionBudgets)
+}
+func (r *resolver) nodes() []corev1.Node {
+	return listed(r, "Node", r.c.Nodes)
+}
+func (r *resolver) namespaces() []corev1.Namespace {
+	return listed(r, "Namespace", r.c.Namespaces)
+}
+func (r *resolver) roles() []rbacv1.Role {
+	return listed(r, "Role", r.c.Roles)
+}
+func (r *resolver) clusterRoles() []rbacv1.ClusterRole {
+	return listed(r, "ClusterRole", r.c.ClusterRoles)
+}
+func (r *resolver) roleBindings() []rbacv1.RoleBinding {
+	return listed(r, "RoleBinding", r.c.RoleBindings)
+}
+func (r *resolver) clusterRoleBindings() []rbacv1.ClusterRoleBinding {
+	return listed(r, "ClusterRoleBinding", r.c.ClusterRoleBindings)
+}
+func (r *resolver) configMaps() []corev1.ConfigMap {
+	return listed(r, "ConfigMap", r.c.ConfigMaps)
+}
+func (r *resolver) secrets() []corev1.Secret {
+	return listed(r, "Secret", r.c.Secrets)
+}
+func (r *resolver) serviceAccounts() []corev1.ServiceAccount {
+	return listed(r, "ServiceAccount", r.c.ServiceAccounts)
+}
+
+// podsIn returns the pods of one namespace.
+func (r *resolver) podsIn(ns string) []*corev1.Pod {
+	return cached(r, "Pod@ns", func() map[string][]*corev1.Pod {
+		pods := r.pods()
+		m := make(map[string][]*corev1.Pod)
+		for i := range pods {
+			m[pods[i].Namespace] = append(m[pods[i].Namespace], &pods[i])
+		}
+		return m
+	})[ns]
+}
+
+// podByIP maps pod IPs back to pods.
+func (r *resolver) podByIP(ip string) *corev1.Pod {
+	return cached(r, "Pod@ip", func() map[string]*corev1.Pod {
+		pods := r.pods()
+		m := make(map[string]*corev1.Pod)
+		for i := range pods {
+			for _, pip := range pods[i].Status.PodIPs {
+				m[pip.IP] = &pods[i]
+			}
+			if pods[i].Status.PodIP != "" {
+				m[pods[i].Status.PodIP] = &pods[i]
+			}
+		}
+		return m
+	})[ip]
+}
+
+// namespaceNames returns every namespace that exists as an object or holds
+// pods, sorted.
+func (r *resolver) namespaceNames() []string {
+	return cached(r, "Namespace@names", func() []string {
+		seen := map[string]bool{}
+		for _, ns := range r.namespaces() {
+			seen[ns.Name] = true
+		}
+		for _, p := range r.pods() {
+			seen[p.Namespace] = true
+		}
+		names := make([]string, 0, len(seen))
+		for ns := range seen {
+			names = append(names, ns)
+		}
+		sort.Strings(names)
+		return names
+	})
+}
+
+// policyEvaluator evaluates reachability under every NetworkPolicy.
+func (r *resolver) policyEvaluator() *PolicyEvaluator {
+	return cached(r, "NetworkPolicy@eval", func() *PolicyEvaluator {
+		return NewPolicyEvaluator(r.networkPolicies(), r.namespaces())
+	})
+}
+
+func (r *resolver) nodeByName(name string) *corev1.Node {
+	return cached(r, "Node@name", func() map[string]*corev1.Node {
+		nodes := r.nodes()
+		m := make(map[string]*corev1.Node, len(nodes))
+		for i := range nodes {
+			m[nodes[i].Name] = &nodes[i]
+		}
+		return m
+	})[name]
+}
+
+func (r *resolver) pvByName(name string) *corev1.PersistentVolume {
+	return cached(r, "PersistentVolume@name", func() map[string]*corev1.PersistentVolume {
+		pvs := r.pvs()
+		m := make(map[string]*corev1.PersistentVolume, len(pvs))
+		for i := range pvs {
+			m[pvs[i].Name] = &pvs[i]
+		}
+		return m
+	})[name]
+}
+
+// ───────────────────────── derive functions ────────────────────────────────
+
+func deriveServicePods(r *resolver, svc *corev1.Service) []Edge {
+	// selector 없는 Service는 Endpoints를 직접 관리하므로 Pod를 고르지 않음
+	if len(svc.Spec.Selector) == 0 {
+		return nil
+	}
+	sel := labels.SelectorFromSet(svc.Spec.Selector)
+	from := r.node(svc.Namespace, svc.Name, "Service")
+	var out []Edge
+	for _, pod := range r.podsIn(svc.Namespace) {
+		if sel.Matches(labels.Set(pod.Labels)) {
+			out = append(out, Edge{From: from, To: r.node(pod.Namespace, pod.Name, "Pod"), Kind: Routes})
+		}
+	}
+	return out
+}
+
+func deriveIngressServices(r *resolver, ing *netv1.Ingress) []Edge {
+	from := r.node(ing.Namespace, ing.Name, "Ingress")
+	var out []Edge
+	route := func(b netv1.IngressBackend, attrs map[string]string) {
+		to, port := r.ingressBackend(ing.Namespace, b)
+		if to == "" {
+			return
+		}
+		if port != "" {
+			attrs["port"] = port
+		}
+		out = append(out, Edge{From: from, To: to, Kind: Routes, Attrs: attrs})
+	}
+
+	// defaultBackend (v1)
+	if db := ing.Spec.DefaultBackend; db != nil {
+		route(*db, map[string]string{"host": "*", "path": "*"})
+	}
+	// rules[].http.paths
+	for _, rule := range ing.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+		for _, path := range rule.HTTP.Paths {
+			attrs := map[string]string{
+				"host": rule.Host,
+				"path": path.Path,
+			}
+			if path.PathType != nil {
+				attrs["pathType"] = string(*path.PathType)
+			}
+			route(path.Backend, attrs)
+		}
+	}
+	return out
+}
+
+// derivePVCVolume links a claim to the volume named in spec.volumeName.
+// That includes claims pre-bound to a volume that are still Pending; the
+// claim's phase is on its node.
+func derivePVCVolume(r *resolver, pvc *corev1.PersistentVolumeClaim) []Edge {
+	if pvc.Spec.VolumeName == "" {
+		return nil
+	}
+	pv := r.pvByName(pvc.Spec.VolumeName)
+	if pv == nil {
+		return nil
+	}
+	return []Edge{{
+		From: r.node(pvc.Namespace, pvc.Name, "PersistentVolumeClaim"),
+		To:   r.node("", pv.Name, "PersistentVolume"),
+		Kind: Binds,
+	}}
+}
+
+func derivePVStorageClass(r *resolver, pv *corev1.PersistentVolume) []Edge {
+	sc := pv.Spec.StorageClassName
+	if sc == "" {
+		sc = "none"
+	}
+	return []Edge{{
+		From: r.node("", pv.Name, "PersistentVolume"),
+		To:   r.node("", sc, "StorageClass"),
+		Kind: Uses,
+	}}
+}
+
+func derivePodServiceAccount(r *resolver, pod *corev1.Pod) []Edge {
+	sa := pod.Spec.ServiceAccountName
+	if sa == "" {
+		sa = "default"
+	}
+	return []Edge{{
+		From: r.node(pod.Namespace, pod.Name, "Pod"),
+		To:   r.node(pod.Namespace, sa, "ServiceAccount"),
+		Kind: Uses,
+	}}
+}
+
+// deriveHPATarget links an HPA to its scaleTargetRef. The edge carries the
+// replica bounds and the last observed replica counts.
+func deriveHPATarget(r *resolver, hpa *autoscalingv2.HorizontalPodAutoscaler) []Edge {
+	ref := hpa.Spec.ScaleTargetRef
+	if ref.Kind == "" || ref.Name == "" {
+		return nil
+	}
+	minReplicas := int32(1)
+	if hpa.Spec.MinReplicas != nil {
+		minReplicas = *hpa.Spec.MinReplicas
+	}
+	return []Edge{{
+		From: r.node(hpa.Namespace, hpa.Name, "HorizontalPodAutoscaler"),
+		To:   r.g.AddKey(RefKey(hpa.Namespace, ref.APIVersion, ref.Kind, ref.Name), ""),
+		Kind: Scales,
+		Attrs: map[string]string{
+			"minReplicas":     strconv.Itoa(int(minReplicas)),
+			"maxReplicas":     strconv.Itoa(int(hpa.Spec.MaxReplicas)),
+			"currentReplicas": strconv.Itoa(int(hpa.Status.CurrentReplicas)),
+			"desiredReplicas": strconv.Itoa(int(hpa.Status.DesiredReplicas)),
+		},
+	}}
+}
+
+// derivePDBPods links a PDB to the pods its selector matches in its own
+// namespace. A nil selector matches nothing and an empty one every pod.
+func derivePDBPods(r *resolver, pdb *policyv1.PodDisruptionBudget) []Edge {
+	if pdb.Spec.Selector == nil {
+		return nil
+	}
+	sel, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector)
+	if err != nil {
+		return nil
+	}
+	attrs := map[string]string{
+		"disruptionsAllowed": strconv.Itoa(int(pdb.Status.DisruptionsAllowed)),
+		"currentHealthy":     strconv.Itoa(int(pdb.Status.CurrentHealthy)),
+		"desiredHealthy":     strconv.Itoa(int(pdb.Status.DesiredHealthy)),
+		"expectedPods":       strconv.Itoa(int(pdb.Status.ExpectedPods)),
+	}
+	if pdb.Spec.MinAvailable != nil {
+		attrs["minAvailable"] = pdb.Spec.MinAvailable.String()
+	}
+	if pdb.Spec.MaxUnavailable != nil {
+		attrs["maxUnavailable"] = pdb.Spec.MaxUnavailable.String()
+	}
+	from := r.node(pdb.Namespace, pdb.Name, "PodDisruptionBudget")
+	var out []Edge
+	for _, pod := range r.podsIn(pdb.Namespace) {
+		if sel.Matches(labels.Set(pod.Labels)) {
+			out = append(out, Edge{From: from, To: r.node(pod.Namespace, pod.Name, "Pod"), Kind: Protects, Attrs: attrs})
+		}
+	}
+	return out
+}
+
+// podNodeRelation re-derives only the pods scheduled to a changed node.
+func podNodeRelation() *relation {
+	rel := newRelation("pod-node", "Pod",
+		[]EdgeKind{RunsOn}, []string{"Node"}, (*resolver).pods, derivePodNode)
+	rel.near = func(_ string, node, src metav1.Object) bool {
+		return src.(*corev1.Pod).Spec.NodeName == node.GetName()
+	}
+	return rel
+}
+
+// derivePodNode links a scheduled pod to its node. The edge records the
+// pod's tolerations, the node's taints and, if any, the taints the pod does
+// not tolerate (e.g. after a node.kubernetes.io/not-ready taint was added).
+func derivePodNode(r *resolver, pod *corev1.Pod) []Edge {
+	if pod.Spec.NodeName == "" {
+		return nil
+	}
+	attrs := map[string]string{}
+	if len(pod.Spec.Tolerations) > 0 {
+		tols := make([]string, 0, len(pod.Spec.Tolerations))
+		for _, t := range pod.Spec.Tolerations {
+			tols = append(tols, tolerationString(t))
+		}
+		attrs["tolerations"] = strings.Join(tols, ",")
+	}
+	if node := r.nodeByName(pod.Spec.NodeName); node != nil && len(node.Spec.Taints) > 0 {
+		var taints, untolerated []string
+		for i := range node.Spec.Taints {
+			taint := &node.Spec.Taints[i]
+			taints = append(taints, taint.ToString())
+			if taint.Effect != corev1.TaintEffectPreferNoSchedule && !tolerates(pod.Spec.Tolerations, taint) {
+				untolerated = append(untolerated, taint.ToString())
+			}
+		}
+		attrs["taints"] = strings.Join(taints, ",")
+		if len(untolerated) > 0 {
+			attrs["untolerated"] = strings.Join(untolerated, ",")
+		}
+	}
+	return []Edge{{
+		From:  r.node(pod.Namespace, pod.Name, "Pod"),
+		To:    r.node("", pod.Spec.NodeName, "Node"),
+		Kind:  RunsOn,
+		Attrs: attrs,
+	}}
+}
+
+func tolerates(tols []corev1.Toleration, taint *corev1.Taint) bool {
+	for i := range tols {
+		if tols[i].ToleratesTaint(taint) {
+			return true
+		}
+	}
+	return false
+}
+
+// tolerationString renders a toleration like a taint: "key=value:Effect",
+// "key:Effect" for Exists, "*" for a wildcard. An empty effect matches all.
+func tolerationString(t corev1.Toleration) string {
+	s := t.Key
+	if s == "" {
+		s = "*"
+	}
+	if t.Operator != corev1.TolerationOpExists && t.Value != "" {
+		s += "=" + t.Value
+	}
+	if t.Effect != "" {
+		s += ":" + string(t.Effect)
+	}
+	return s
+}
+
+// topologyLabels maps the well-known node labels, current first, to the
+// topology kind they name.
+var topologyLabels = []struct{ label, kind string }{
+	{corev1.LabelTopologyZone, "Zone"},
+	{corev1.LabelFailureDomainBetaZone, "Zone"},
+	{corev1.LabelTopologyRegion, "Region"},
+	{corev1.LabelFailureDomainBetaRegion, "Region"},
+}
+
+func deriveNodeTopology(r *resolver, node *corev1.Node) []Edge {
+	from := r.node("", node.Name, "Node")
+	var out []Edge
+	seen := map[string]bool{}
+	for _, tl := range topologyLabels {
+		v := node.Labels[tl.label]
+		if v == "" || seen[tl.kind] {
+			continue
+		}
+		seen[tl.kind] = true
+		out = append(out, Edge{From: from, To: r.node("", v, tl.kind), Kind: LocatedIn})
+	}
+	return out
+}
@@ -0,0 +1,56 @@
+package collector
+
+import (
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Snapshot is a point-in-time view of a Graph. Its maps are shared with
+// other snapshots of the same version and must be treated as read-only.
+type Snapshot struct {
+	Nodes map[string]Node // UID -> Node
+	Edges map[string]Edge // edgeID -> Edge
+
+	edgeMap     map[string]map[string]struct{}
+	byObjectUID map[types.UID]string
+}
+
+// Lookup finds the node for kind/namespace/name.
+func (s *Snapshot) Lookup(kind, ns, name string) (Node, bool) {
+	n, ok := s.Nodes[KeyOf(kind, ns, name).ID()]
+	return n, ok
+}
+
+// LookupUID finds the node backed by the object with the given metadata.uid.
+func (s *Snapshot) LookupUID(objUID types.UID) (Node, bool) {
+	uid, ok := s.byObjectUID[objUID]
+	if !ok {
+		return Node{}, false
+	}
+	n, ok := s.Nodes[uid]
+	return n, ok
+}
+
+// Incident returns the edges that start or end at uid.
+func (s *Snapshot) Incident(uid string) []Edge {
+	out := make([]Edge, 0, len(s.edgeMap[uid]))
+	for id := range s.edgeMap[uid] {
+		out = append(out, s.Edges[id])
+	}
+	return out
+}
+
+// NewSnapshot rebuilds a snapshot from nodes and edges saved earlier, such
+// as the CSV exports. Node UIDs and edge IDs are derived again from the
+// keys and attributes.
+func NewSnapshot(nodes []Node, edges []Edge) *Snapshot {
+	g := NewGraph()
+	g.mu.Lock()
+	for _, n := range nodes {
+		g.addKey(n.Key(), n.ObjectUID, n.Props)
+	}
+	for _, e := range edges {
+		g.addEdge(e)
+	}
+	g.mu.Unlock()
+	return g.Snapshot()
+}
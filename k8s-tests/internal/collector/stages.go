@@ -6,201 +6,193 @@ import (
 	"net/http"
 	"fmt"
 	"log"
+	"strconv"
+	"time"
 
-	corev1 "k8s.io/api/core/v1"
-	discv1 "k8s.io/api/discovery/v1"
 	netv1 "k8s.io/api/networking/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/labels"
 )
 
 
 // ───────────────────────── Workload (Deployment, Service) ──────────────────
-func WorkloadStage(ctx context.Context, c *Client, g *Graph) error {
-	before := len(g.Edges)
-	for _, dp := range c.Deployments(ctx) {
-		dpUID := g.AddNode(dp.Namespace, dp.Name, "Deployment")
-
-		for _, rs := range c.ReplicaSetsForDeployment(ctx, dp) {
-			rsUID := g.AddNode(rs.Namespace, rs.Name, "ReplicaSet")
-			g.AddEdge(dpUID, rsUID, Owns)
-
-			for _, pod := range c.PodsForReplicaSet(ctx, rs) {
-				podUID := g.AddNode(pod.Namespace, pod.Name, "Pod")
-				g.AddEdge(rsUID, podUID, Owns)
-			}
-		}
-	}
-
-	for _, svc := range c.Services(ctx) {
-		svcUID := g.AddNode(svc.Namespace, svc.Name, "Service")
-		for _, pod := range c.PodsForService(ctx, svc) {
-			podUID := g.AddNode(pod.Namespace, pod.Name, "Pod")
-			g.AddEdge(svcUID, podUID, Routes)
-		}
-	}
+func WorkloadStage(ctx context.Context, c Client, g *Graph) error {
+	before := g.EdgeCount()
+	// 소유 관계는 ownerReferences 기준 (Pod 쪽이 RS/DS/STS/Job 어느 것이든 가리킴)
+	r := newResolver(ctx, c, g)
+	err := r.run(append(ownersOf("Deployment", "ReplicaSet", "Pod"), servicePods)...)
 	defer func() {
-		added := len(g.Edges) - before
+		added := g.EdgeCount() - before
 		fmt.Printf("[WorkloadStage] targets added=%d\n", added)
 	}()
-	return nil
+	return err
 }
 
 
-// ───────────────────────── Ingress / Gateway  ──────────────────────────────
-func IngressStage(ctx context.Context, c *Client, g *Graph) error {
-    before := len(g.Edges)
+// ───────────────────────── Ownership (ownerReferences) ─────────────────────
+// OwnershipStage walks metadata.ownerReferences of every collected kind.
+func OwnershipStage(ctx context.Context, c Client, g *Graph) error {
+	before := g.EdgeCount()
+	err := newResolver(ctx, c, g).run(ownerRelations...)
+	fmt.Printf("[OwnershipStage] owns added=%d\n", g.EdgeCount()-before)
+	return err
+}
 
-	var ings netv1.IngressList
-	r := c.Resources().WithNamespace("")
-	if err := r.List(ctx, &ings); err != nil {
-		log.Printf("[IngressStage] list error: %v", err)
-	} else {
-		log.Printf("[IngressStage] found ingress count=%d", len(ings.Items))
+// ───────────────────────── Helm releases ───────────────────────────────────
+// HelmStage links every object carrying meta.helm.sh annotations to its
+// HelmRelease and fills the release nodes from the Helm release Secrets.
+func HelmStage(ctx context.Context, c Client, g *Graph) error {
+	before := g.EdgeCount()
+	r := newResolver(ctx, c, g)
+	err := r.run(partOfRelations...)
+	secrets := r.secrets()
+	for i := range secrets {
+		markReleaseSecret(g, &secrets[i])
 	}
+	markHelmReleases(g, nodesOf(g, "HelmRelease"))
+	fmt.Printf("[HelmStage] part-of added=%d\n", g.EdgeCount()-before)
+	return err
+}
 
-    // 2) 각 Ingress 처리
-    for _, ing := range ings.Items {
-        uid := g.AddNode(ing.Namespace, ing.Name, "Ingress")
+// ───────────────────────── OpenStack services ──────────────────────────────
+// OpenStackStage maps openstack-helm workloads to OpenStack service
+// components through their application/component labels.
+func OpenStackStage(ctx context.Context, c Client, g *Graph) error {
+	before := g.EdgeCount()
+	err := newResolver(ctx, c, g).run(openstackRelations...)
+	fmt.Printf("[OpenStackStage] added=%d edges\n", g.EdgeCount()-before)
+	return err
+}
 
-        // 2-A) defaultBackend (v1)
-        if db := ing.Spec.DefaultBackend; db != nil {
-            if svc := db.Service; svc != nil {
-                sid := g.AddNode(ing.Namespace, svc.Name, "Service")
-                g.AddEdge(uid, sid, Routes)
-            }
-        }
+// keystoneTimeout bounds the catalog request, so an unresponsive Keystone
+// cannot hold up the collection.
+var keystoneTimeout = 30 * time.Second
+
+// KeystoneCatalogStage links OpenStack services to the Ingresses and
+// Services their catalog endpoints resolve to. source is a catalog file or
+// a Keystone /v3/auth/catalog URL (see LoadKeystoneCatalog). The catalog is
+// read once per full collection and its edges are not relinked on events.
+// An unreadable catalog, or one not read within keystoneTimeout, marks the
+// KeystoneCatalog kind as failed instead of failing the collection.
+func KeystoneCatalogStage(source string) Stage {
+	return func(ctx context.Context, c Client, g *Graph) error {
+		loadCtx, cancel := context.WithTimeout(ctx, keystoneTimeout)
+		catalog, err := LoadKeystoneCatalog(loadCtx, source)
+		cancel()
+		if err != nil {
+			return &DegradedError{Err: &ListError{Kind: "KeystoneCatalog", Err: err}}
+		}
+		before := g.EdgeCount()
+		r := newResolver(ctx, c, g)
+		for _, e := range r.catalogEdges(catalog) {
+			g.AddEdgeAttrs(e.From, e.To, e.Kind, e.Attrs)
+		}
+		fmt.Printf("[KeystoneCatalogStage] services=%d exposes added=%d\n", len(catalog), g.EdgeCount()-before)
+		return r.err()
+	}
+}
 
-        // 2-B) rules[].http.paths
-        for _, rule := range ing.Spec.Rules {
-            if rule.HTTP == nil {
-                continue
-            }
-            for _, path := range rule.HTTP.Paths {
-                // v1 방식
-                if path.Backend.Service != nil {
-                    svc := path.Backend.Service
-                    sid := g.AddNode(ing.Namespace, svc.Name, "Service")
-                    g.AddEdge(uid, sid, Routes)
-                    continue
-                }
-            }
-        }
-    }
+// ───────────────────────── Node placement / topology ──────────────────────
+// TopologyStage adds Node objects, Pod→Node placement and Node→Zone/Region.
+func TopologyStage(ctx context.Context, c Client, g *Graph) error {
+	before := g.EdgeCount()
+	err := newResolver(ctx, c, g).run(nodeTopology, podNodes)
+	fmt.Printf("[TopologyStage] added=%d edges\n", g.EdgeCount()-before)
+	return err
+}
 
-    // 3) 로깅
-    added := len(g.Edges) - before
+// ───────────────────────── HPA / PDB / CronJob ─────────────────────────────
+// AutoscalingStage links HPAs to the workloads they scale, PDBs to the pods
+// they protect, and CronJobs to the Jobs they spawn.
+func AutoscalingStage(ctx context.Context, c Client, g *Graph) error {
+	before := g.EdgeCount()
+	err := newResolver(ctx, c, g).run(append([]*relation{hpaTargets, pdbPods}, ownersOf("CronJob", "Job")...)...)
+	fmt.Printf("[AutoscalingStage] added=%d edges\n", g.EdgeCount()-before)
+	return err
+}
+
+// ───────────────────────── Ingress / Gateway  ──────────────────────────────
+func IngressStage(ctx context.Context, c Client, g *Graph) error {
+    before := g.EdgeCount()
+
+	r := newResolver(ctx, c, g)
+	log.Printf("[IngressStage] found ingress count=%d", len(r.ingresses()))
+	err := r.run(ingressServices, ingressClassRefs, ingressTLS, ingressClassPods)
+
+    added := g.EdgeCount() - before
     fmt.Printf("[IngressStage] routes added=%d\n", added)
-    return nil
+    return err
 }
-// ───────────────────────── EndpointSlice → Pod  ────────────────────────────
-func EndpointStage(ctx context.Context, c *Client, g *Graph) error {
-	before := len(g.Edges)
-    var esList discv1.EndpointSliceList
-    _ = c.Resources().List(ctx, &esList)
-
-    // ── Pod IP → Pod 캐시 ---------------------------------
-    ipMap := make(map[string]corev1.Pod)
-    for _, pod := range c.PodsBySelector(ctx, "", nil) {      // 모든 ns
-        for _, ip := range pod.Status.PodIPs {
-            ipMap[ip.IP] = pod
-        }
-		if pod.Status.PodIP != "" {                       
-			ipMap[pod.Status.PodIP] = pod
-		}
-    }
 
-    // ── Slice → Pod edges ---------------------------------
-    for _, es := range esList.Items {
-        esUID := g.AddNode(es.Namespace, es.Name, "EndpointSlice")
-        for _, ep := range es.Endpoints {
-            // 1) targetRef 있으면 그대로
-            if ep.TargetRef != nil && ep.TargetRef.Kind == "Pod" {
-                p := ep.TargetRef
-                pUID := g.AddNode(es.Namespace, p.Name, "Pod")
-                g.AddEdge(esUID, pUID, Targets)
-                continue
-            }
-            // 2) 없으면 IP 역‑매핑
-            for _, addr := range ep.Addresses {
-                if pod, ok := ipMap[addr]; ok {
-                    pUID := g.AddNode(pod.Namespace, pod.Name, "Pod")
-                    g.AddEdge(esUID, pUID, Targets)
-                }
-            }
-        }
-    }
+// GatewayStage maps Gateway API objects down to Services: GatewayClasses to
+// their controller pods, Gateways to classes and certificates, and
+// HTTPRoutes and GRPCRoutes from their parent Gateways to their backends.
+// ReferenceGrants only become nodes; their effect is on the route edges.
+func GatewayStage(ctx context.Context, c Client, g *Graph) error {
+	before := g.EdgeCount()
+	r := newResolver(ctx, c, g)
+	grants := r.gatewayObjects("ReferenceGrant")
+	for i := range grants {
+		g.AddObject("ReferenceGrant", &grants[i])
+	}
+	err := r.run(gatewayClassPods, gatewayRefs,
+		httpRouteParents, httpRouteBackends, grpcRouteParents, grpcRouteBackends)
+	fmt.Printf("[GatewayStage] added=%d edges\n", g.EdgeCount()-before)
+	return err
+}
+
+// backendPort renders an Ingress service port as its number or name.
+func backendPort(p netv1.ServiceBackendPort) string {
+	if p.Name != "" {
+		return p.Name
+	}
+	return strconv.Itoa(int(p.Number))
+}
+// ───────────────────────── EndpointSlice → Pod  ────────────────────────────
+func EndpointStage(ctx context.Context, c Client, g *Graph) error {
+	before := g.EdgeCount()
+	r := newResolver(ctx, c, g)
+	// 슬라이스가 하나도 없는 Service도 noReadyEndpoints로 표시되도록 노드 추가
+	services := r.services()
+	for i := range services {
+		g.AddObject("Service", &services[i])
+	}
+	err := r.run(serviceSlices, endpointSlicePods)
+	markServiceReadiness(g, nodesOf(g, "Service"))
 	defer func() {
-		added := len(g.Edges) - before
+		added := g.EdgeCount() - before
 		fmt.Printf("[EndpointStage] targets added=%d\n", added)
 	}()
 
-    return nil
+	return err
 }
 
-func PVCStage(ctx context.Context, c *Client, g *Graph) error {
-	// 기존 edge 개수 기록
-	before := len(g.Edges)
-
-	// 1) PVC 목록
-	r := c.Resources().WithNamespace("")  
-	var pvcList corev1.PersistentVolumeClaimList
-	if err := r.List(ctx, &pvcList); err != nil {
-		fmt.Printf("[PVCStage] list PVC error: %v\n", err)
-		return nil
-	}
-	fmt.Printf("[PVCStage] found PVCs=%d\n", len(pvcList.Items))
-
-	// 2) PV 목록
-	var pvList corev1.PersistentVolumeList
-	_ = r.List(ctx, &pvList)
-	pvMap := make(map[string]*corev1.PersistentVolume, len(pvList.Items))
-	for i := range pvList.Items {
-		pv := &pvList.Items[i]
-		pvMap[pv.Name] = pv
-	}
-	fmt.Printf("[PVCStage] found PVs=%d\n", len(pvList.Items))
+func PVCStage(ctx context.Context, c Client, g *Graph) error {
+	before := g.EdgeCount()
 
-	// 3) Edge 생성
-	for _, pvc := range pvcList.Items {
+	r := newResolver(ctx, c, g)
+	pvcs := r.pvcs()
+	fmt.Printf("[PVCStage] found PVCs=%d PVs=%d\n", len(pvcs), len(r.pvs()))
+	for _, pvc := range pvcs {
 		fmt.Printf("[PVCStage] pvc %s phase=%s volumeName=%q\n",
 			pvc.Name, pvc.Status.Phase, pvc.Spec.VolumeName)
-
-		if pvc.Status.Phase != corev1.ClaimBound {
-			continue
-		}
-
-		pvcUID := g.AddNode(pvc.Namespace, pvc.Name, "PVC")
-		if pv, exists := pvMap[pvc.Spec.VolumeName]; exists {
-			pvUID := g.AddNode("", pv.Name, "PV")
-			g.AddEdge(pvcUID, pvUID, Binds)
-
-			sc := pv.Spec.StorageClassName
-			if sc == "" {
-				sc = "none"
-			}
-			scUID := g.AddNode("", sc, "StorageClass")
-			g.AddEdge(pvUID, scUID, Uses)
-		}
 	}
+	err := r.run(pvcVolumes, pvcStorageClasses, pvStorageClasses,
+		podClaims, claimTemplates, volumeAttachments)
 
-	// 4) 현재 전체 Edges 중 새로 추가된 것만 수집 (간접 추정)
 	counts := map[EdgeKind]int{}
-	if len(g.Edges) > before {
-		// map이므로 순회하면서 "index > before"인 것만 필터링 불가 → 전체 순회로 대체
-		for _, e := range g.Edges {
+	if g.EdgeCount() > before {
+		for _, e := range g.Snapshot().Edges {
 			counts[e.Kind]++
 		}
 	}
-	fmt.Printf("[PVCStage] binds=%d uses=%d\n",
-		counts[Binds], counts[Uses])
+	fmt.Printf("[PVCStage] binds=%d uses=%d mounts=%d attaches=%d\n",
+		counts[Binds], counts[Uses], counts[Mounts], counts[Attaches])
 
-	return nil
+	return err
 }
 
 /*
 // ───────────────────────── PVC → PV → StorageClass ─────────────────────────
-func PVCStage(ctx context.Context, c *Client, g *Graph) error {
+func PVCStage(ctx context.Context, c Client, g *Graph) error {
     before := len(g.Edges)
 
     // 1) 전역 스코프에서 PVC 목록
@@ -232,9 +224,9 @@ func PVCStage(ctx context.Context, c *Client, g *Graph) error {
             continue
         }
 
-        pvcUID := g.AddNode(pvc.Namespace, pvc.Name, "PVC")
+        pvcUID := g.AddObject("PersistentVolumeClaim", &pvc)
         if pv, exists := pvMap[pvc.Spec.VolumeName]; exists {
-            pvUID := g.AddNode("", pv.Name, "PV")
+            pvUID := g.AddObject("PersistentVolume", pv)
             g.AddEdge(pvcUID, pvUID, Binds)
 
             sc := pv.Spec.StorageClassName
@@ -260,120 +252,28 @@ func PVCStage(ctx context.Context, c *Client, g *Graph) error {
 */
 
 // ───────────────────────── DaemonSet / StatefulSet ─────────────────────────
-func DSSTSStage(ctx context.Context, c *Client, g *Graph) error {
-	before := len(g.Edges)
-	for _, ds := range c.DaemonSets(ctx) {
-		dsUID := g.AddNode(ds.Namespace, ds.Name, "DaemonSet")
-		for _, pod := range c.PodsBySelector(ctx, ds.Namespace, ds.Spec.Selector.MatchLabels) {
-			pUID := g.AddNode(pod.Namespace, pod.Name, "Pod")
-			g.AddEdge(dsUID, pUID, Owns)
-		}
-	}
-	for _, st := range c.StatefulSets(ctx) {
-		stUID := g.AddNode(st.Namespace, st.Name, "StatefulSet")
-		for _, pod := range c.PodsBySelector(ctx, st.Namespace, st.Spec.Selector.MatchLabels) {
-			pUID := g.AddNode(pod.Namespace, pod.Name, "Pod")
-			g.AddEdge(stUID, pUID, Owns)
-		}
-	}
+func DSSTSStage(ctx context.Context, c Client, g *Graph) error {
+	before := g.EdgeCount()
+	err := newResolver(ctx, c, g).run(append(ownersOf("DaemonSet", "StatefulSet", "ControllerRevision"), podOwners("DaemonSet", "StatefulSet"))...)
 	defer func() {
-		added := len(g.Edges) - before
+		added := g.EdgeCount() - before
 		fmt.Printf("[DSSTSSStage] targets added=%d\n", added)
 	}()
-	return nil
+	return err
 }
 
-func NetpolStage(ctx context.Context, c *Client, g *Graph) error {
-	// 기존 edge 개수 기록 (현재는 참고용)
-	//before := len(g.Edges)
+func NetpolStage(ctx context.Context, c Client, g *Graph) error {
+	r := newResolver(ctx, c, g)
+	fmt.Printf("[NetpolStage] found NetPol=%d Pods=%d\n", len(r.networkPolicies()), len(r.pods()))
+	before := g.EdgeCount()
+	err := r.run(netpolSelects, netpolPeers)
+	fmt.Printf("[NetpolStage] added=%d edges\n", g.EdgeCount()-before)
 
-	// 1) 전역(All-NS) NP·Pod 조회
-	r := c.Resources().WithNamespace("")
-	var nps netv1.NetworkPolicyList
-	if err := r.List(ctx, &nps); err != nil {
-		fmt.Printf("[NetpolStage] list NP error: %v\n", err)
-		return nil
-	}
-	var podList corev1.PodList
-	_ = r.List(ctx, &podList)
-	pods := podList.Items
-	fmt.Printf("[NetpolStage] found NetPol=%d Pods=%d\n", len(nps.Items), len(pods))
-
-	for _, np := range nps.Items {
-		// 2) 대상 Pod 집합
-		selector := &np.Spec.PodSelector
-		tgt := selectPods(pods, selector)
-		if (len(selector.MatchLabels) == 0 && len(selector.MatchExpressions) == 0) || len(tgt) == 0 {
-			tgt = pods
-		}
-		fmt.Printf("[NetpolStage] %s targetPods=%d\n", np.Name, len(tgt))
-
-		// 3) Ingress 룰이 없으면 모든 Pod → 타겟 Pod 허용
-		/*
-		if len(np.Spec.Ingress) == 0 {
-			for _, sp := range pods {
-				sUID := g.AddNode(sp.Namespace, sp.Name, "Pod")
-				for _, tp := range tgt {
-					tUID := g.AddNode(tp.Namespace, tp.Name, "Pod")
-					g.AddEdge(sUID, tUID, Allow)
-				}
-			}
-			continue
-		}
-		*/
-		
-		// 4) 각 Ingress 룰 처리
-		for _, rule := range np.Spec.Ingress {
-			var srcPods []corev1.Pod
-			if len(rule.From) == 0 {
-				srcPods = pods
-			} else {
-				for _, peer := range rule.From {
-					if peer.PodSelector == nil ||
-						(len(peer.PodSelector.MatchLabels) == 0 && len(peer.PodSelector.MatchExpressions) == 0) {
-						srcPods = pods
-						break
-					}
-					matched := selectPods(pods, peer.PodSelector)
-					srcPods = append(srcPods, matched...)
-				}
-			}
-
-			// 5) 중복 제거
-			uniq := make(map[string]corev1.Pod)
-			for _, p := range srcPods {
-				uniq[p.Name] = p
-			}
-			srcPods = srcPods[:0]
-			for _, p := range uniq {
-				srcPods = append(srcPods, p)
-			}
-
-			// 6) 엣지 추가
-			for _, sp := range srcPods {
-				sUID := g.AddNode(sp.Namespace, sp.Name, "Pod")
-				for _, tp := range tgt {
-					tUID := g.AddNode(tp.Namespace, tp.Name, "Pod")
-					g.AddEdge(sUID, tUID, Allow)
-				}
-			}
-		}
-	}
-
-	// 전체 Edge 중 Allow 타입만 카운트
-	added := 0
-	for _, e := range g.Edges {
-		if e.Kind == Allow {
-			added++
-		}
-	}
-	fmt.Printf("[NetpolStage] allow edges added=%d (total Allow edges)\n", added)
-
-	return nil
+	return err
 }
 
 /*
-func NetpolStage(ctx context.Context, c *Client, g *Graph) error {
+func NetpolStage(ctx context.Context, c Client, g *Graph) error {
     before := len(g.Edges)
 
     // 1) 전역(All-NS) NP·Pod 조회
@@ -400,9 +300,9 @@ func NetpolStage(ctx context.Context, c *Client, g *Graph) error {
         // 3) Ingress 룰이 비어 있으면 “all→tgt” 엣지
         if len(np.Spec.Ingress) == 0 {
             for _, sp := range pods {
-                sUID := g.AddNode(sp.Namespace, sp.Name, "Pod")
+                sUID := g.AddObject("Pod", &sp)
                 for _, tp := range tgt {
-                    tUID := g.AddNode(tp.Namespace, tp.Name, "Pod")
+                    tUID := g.AddObject("Pod", &tp)
                     g.AddEdge(sUID, tUID, Allow)
                 }
             }
@@ -437,9 +337,9 @@ func NetpolStage(ctx context.Context, c *Client, g *Graph) error {
 
             // 6) 엣지 추가
             for _, sp := range srcPods {
-                sUID := g.AddNode(sp.Namespace, sp.Name, "Pod")
+                sUID := g.AddObject("Pod", &sp)
                 for _, tp := range tgt {
-                    tUID := g.AddNode(tp.Namespace, tp.Name, "Pod")
+                    tUID := g.AddObject("Pod", &tp)
                     g.AddEdge(sUID, tUID, Allow)
                 }
             }
@@ -458,106 +358,74 @@ func NetpolStage(ctx context.Context, c *Client, g *Graph) error {
 }
 */
 
-// selectPods: nil 또는 빈 Selector → all, else match labels/expressions
-func selectPods(all []corev1.Pod, sel *metav1.LabelSelector) []corev1.Pod {
-    if sel == nil ||
-        (len(sel.MatchLabels) == 0 && len(sel.MatchExpressions) == 0) {
-        return all
-    }
-    s, _ := metav1.LabelSelectorAsSelector(sel)
-    out := make([]corev1.Pod, 0, len(all))
-    for _, p := range all {
-        if s.Matches(labels.Set(p.Labels)) {
-            out = append(out, p)
-        }
-    }
-    return out
+func JobStage(ctx context.Context, c Client, g *Graph) error {
+	before := g.EdgeCount()
+	err := newResolver(ctx, c, g).run(append(ownersOf("CronJob", "Job"), podOwners("Job"))...)
+	fmt.Printf("[JobStage] added=%d edges\n", g.EdgeCount()-before)
+	return err
 }
 
-
-func JobStage(ctx context.Context, c *Client, g *Graph) error {
-	before := len(g.Edges)
-
-	for _, job := range c.Jobs(ctx) {
-		jobUID := g.AddNode(job.Namespace, job.Name, "Job")
-
-		pods := c.PodsBySelector(ctx, job.Namespace, job.Spec.Selector.MatchLabels)
-		for _, pod := range pods {
-			podUID := g.AddNode(pod.Namespace, pod.Name, "Pod")
-			g.AddEdge(jobUID, podUID, Owns)
-		}
-	}
-	fmt.Printf("[JobStage] added=%d edges\n", len(g.Edges)-before)
-	return nil
+func ConfigSecretStage(ctx context.Context, c Client, g *Graph) error {
+	before := g.EdgeCount()
+	err := newResolver(ctx, c, g).run(podConfigs)
+	fmt.Printf("[ConfigSecretStage] added=%d edges\n", g.EdgeCount()-before)
+	return err
 }
 
-func ConfigSecretStage(ctx context.Context, c *Client, g *Graph) error {
-	before := len(g.Edges)
-
-	for _, pod := range c.PodsBySelector(ctx, "", nil) {
-		podUID := g.AddNode(pod.Namespace, pod.Name, "Pod")
-
-		for _, container := range pod.Spec.Containers {
-			// EnvFrom: ConfigMapRef / SecretRef
-			for _, envFrom := range container.EnvFrom {
-				if envFrom.ConfigMapRef != nil {
-					cmUID := g.AddNode(pod.Namespace, envFrom.ConfigMapRef.Name, "ConfigMap")
-					g.AddEdge(podUID, cmUID, Reads)
-				}
-				if envFrom.SecretRef != nil {
-					secUID := g.AddNode(pod.Namespace, envFrom.SecretRef.Name, "Secret")
-					g.AddEdge(podUID, secUID, Reads)
-				}
-			}
-		}
-
-		// VolumeMount: ConfigMap / Secret
-		for _, vol := range pod.Spec.Volumes {
-			switch {
-			case vol.ConfigMap != nil:
-				cmUID := g.AddNode(pod.Namespace, vol.ConfigMap.Name, "ConfigMap")
-				g.AddEdge(podUID, cmUID, Mounts)
-			case vol.Secret != nil:
-				secUID := g.AddNode(pod.Namespace, vol.Secret.SecretName, "Secret")
-				g.AddEdge(podUID, secUID, Mounts)
-			}
-		}
-	}
-
-	fmt.Printf("[ConfigSecretStage] added=%d edges\n", len(g.Edges)-before)
-	return nil
+// DependencyStage adds depends-on edges from pods to the backend Services
+// (MariaDB, RabbitMQ, memcached, ...) named in the ConfigMaps and Secrets
+// they read.
+func DependencyStage(ctx context.Context, c Client, g *Graph) error {
+	before := g.EdgeCount()
+	err := newResolver(ctx, c, g).run(configDependencies...)
+	fmt.Printf("[DependencyStage] depends-on added=%d\n", g.EdgeCount()-before)
+	return err
 }
 
-func ServiceAccountStage(ctx context.Context, c *Client, g *Graph) error {
-	before := len(g.Edges)
-
-	for _, pod := range c.PodsBySelector(ctx, "", nil) {
-		podUID := g.AddNode(pod.Namespace, pod.Name, "Pod")
+// EntrypointStage adds the startup dependencies openstack-helm pods declare
+// to kubernetes-entrypoint and flags pods stuck in Init on them.
+func EntrypointStage(ctx context.Context, c Client, g *Graph) error {
+	before := g.EdgeCount()
+	err := newResolver(ctx, c, g).run(podWaits)
+	markInitWaits(g, nodesOf(g, "Pod"))
+	fmt.Printf("[EntrypointStage] waits-for added=%d\n", g.EdgeCount()-before)
+	return err
+}
 
-		sa := pod.Spec.ServiceAccountName
-		if sa == "" {
-			sa = "default"
-		}
-		saUID := g.AddNode(pod.Namespace, sa, "ServiceAccount")
-		g.AddEdge(podUID, saUID, Uses)
-	}
+func ServiceAccountStage(ctx context.Context, c Client, g *Graph) error {
+	before := g.EdgeCount()
+	err := newResolver(ctx, c, g).run(podServiceAccounts)
+	fmt.Printf("[ServiceAccountStage] added=%d edges\n", g.EdgeCount()-before)
+	return err
+}
 
-	fmt.Printf("[ServiceAccountStage] added=%d edges\n", len(g.Edges)-before)
-	return nil
+// RBACStage extends Pod→ServiceAccount through RoleBindings and
+// ClusterRoleBindings to the Roles and ClusterRoles they grant, and on to the
+// API resources those permit.
+func RBACStage(ctx context.Context, c Client, g *Graph) error {
+	before := g.EdgeCount()
+	err := newResolver(ctx, c, g).run(roleBindings, clusterRoleBindings, roleRules, clusterRoleRules)
+	fmt.Printf("[RBACStage] added=%d edges\n", g.EdgeCount()-before)
+	return err
 }
 
 // ───────────────────────── Jaeger Deep‑Dependencies ────────────────────────
 func JaegerStage(api string) Stage {
-	return func(ctx context.Context, c *Client, g *Graph) error {
+	return func(ctx context.Context, c Client, g *Graph) error {
 		resp, err := http.Get(api + "/api/dependencies?lookback=3600")
 		if err != nil { return nil } // Jaeger 미구축 시 무시
 		defer resp.Body.Close()
-		var deps []struct{ Parent, Child string }
+		var deps []struct {
+			Parent, Child string
+			CallCount     int64
+		}
 		_ = json.NewDecoder(resp.Body).Decode(&deps)
 		for _, d := range deps {
 			from := g.AddNode("", d.Parent, "Service")
 			to   := g.AddNode("", d.Child,  "Service")
-			g.AddEdge(from, to, Calls)
+			g.AddEdgeAttrs(from, to, Calls, map[string]string{
+				"count": strconv.FormatInt(d.CallCount, 10),
+			})
 		}
 		return nil
 	}
@@ -0,0 +1,880 @@
+package collector
+
+import (
+	"context"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/kaist2025/k8s-e2e-tests/internal/k8sclient"
+)
+
+var update = flag.Bool("update", false, "rewrite testdata/*.golden from the current output")
+
+// Each case runs one stage over testdata/<name>.yaml and compares the
+// resulting graph with testdata/<name>.golden.
+func TestStagesGolden(t *testing.T) {
+	cases := []struct {
+		name  string
+		stage Stage
+	}{
+		{"workload", WorkloadStage},
+		{"ingress", IngressStage},
+		{"gateway", GatewayStage},
+		{"endpoint", EndpointStage},
+		{"dssts", DSSTSStage},
+		{"pvc", PVCStage},
+		{"netpol", NetpolStage},
+		{"job", JobStage},
+		{"configsecret", ConfigSecretStage},
+		{"serviceaccount", ServiceAccountStage},
+		{"ownership", OwnershipStage},
+		{"autoscaling", AutoscalingStage},
+		{"topology", TopologyStage},
+		{"rbac", RBACStage},
+		{"helm", HelmStage},
+		{"openstack", OpenStackStage},
+		{"dependency", DependencyStage},
+		{"entrypoint", EntrypointStage},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := loadFake(t, tc.name+".yaml")
+			g := NewGraph()
+			if err := tc.stage(context.Background(), c, g); err != nil {
+				t.Fatalf("stage: %v", err)
+			}
+			checkGolden(t, tc.name+".golden", dumpGraph(g.Snapshot()))
+		})
+	}
+}
+
+// Pods created after the initial run must pick up the same edges a full
+// re-collect would give them, and lose them again when deleted.
+func TestApplyEventRelinksLikeFullRun(t *testing.T) {
+	stages := []Stage{WorkloadStage, ServiceAccountStage}
+	c := loadFake(t, "workload.yaml")
+	co := NewCollector(c, stages)
+	if _, err := co.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	fullRun := func() string {
+		full := NewCollector(c, stages)
+		if _, err := full.Run(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		return dumpGraph(full.Graph.Snapshot())
+	}
+
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Namespace: "openstack", Name: "keystone-api-6b8f9-zz9rt",
+		Labels: map[string]string{"application": "keystone", "component": "api"},
+		OwnerReferences: []metav1.OwnerReference{{
+			APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "keystone-api-6b8f9", UID: "rs1",
+		}},
+	}}
+	if err := c.Upsert(pod); err != nil {
+		t.Fatal(err)
+	}
+	co.ApplyEvent("Pod", "add", pod)
+	if got, want := dumpGraph(co.Graph.Snapshot()), fullRun(); got != want {
+		t.Fatalf("after add:\n%s\nwant:\n%s", got, want)
+	}
+
+	if err := c.Delete(pod); err != nil {
+		t.Fatal(err)
+	}
+	co.ApplyEvent("Pod", "delete", pod)
+	if got, want := dumpGraph(co.Graph.Snapshot()), fullRun(); got != want {
+		t.Fatalf("after delete:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+// The informers' initial list and resyncs replay objects the graph already
+// holds; those must not rewrite it.
+func TestApplyEventSkipsUnchangedObjects(t *testing.T) {
+	c := loadFake(t, "workload.yaml")
+	co := NewCollector(c, []Stage{WorkloadStage})
+	if _, err := co.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	pods, err := c.Pods(context.Background())
+	if err != nil || len(pods) == 0 {
+		t.Fatalf("pods = %d, %v", len(pods), err)
+	}
+	pod := pods[0]
+	pod.ResourceVersion = "4711"
+	if err := c.Upsert(&pod); err != nil {
+		t.Fatal(err)
+	}
+	co.ApplyEvent("Pod", "update", &pod)
+	before := co.Graph.Snapshot()
+	co.ApplyEvent("Pod", "update", &pod)
+	if co.Graph.Snapshot() != before {
+		t.Fatal("replayed pod rewrote the graph")
+	}
+}
+
+// Queued events are applied by one worker once the first run is done, and
+// the events still queued for an object collapse into the latest one.
+func TestEnqueuedEventsRelinkAfterRun(t *testing.T) {
+	stages := []Stage{WorkloadStage, ServiceAccountStage}
+	c := loadFake(t, "workload.yaml")
+	co := NewCollector(c, stages)
+	newPod := func(name string) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Namespace: "openstack", Name: name,
+			Labels: map[string]string{"application": "keystone", "component": "api"},
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "keystone-api-6b8f9", UID: "rs1",
+			}},
+		}}
+	}
+
+	// 첫 Run 전에는 노드만 반영되고 edge는 Run이 만듦
+	early := newPod("keystone-api-6b8f9-early")
+	co.ApplyEvent("Pod", "add", early)
+	if uid := KeyOf("Pod", early.Namespace, early.Name).ID(); len(co.Graph.Incident(uid)) != 0 {
+		t.Fatalf("relinked before the first run: %v", co.Graph.Incident(uid))
+	}
+	if _, err := co.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	kept, gone := newPod("keystone-api-6b8f9-zz9rt"), newPod("keystone-api-6b8f9-gone")
+	for _, pod := range []*corev1.Pod{kept, gone} {
+		if err := c.Upsert(pod); err != nil {
+			t.Fatal(err)
+		}
+		co.Enqueue("Pod", "add", pod)
+	}
+	co.Enqueue("Pod", "update", gone)
+	if err := c.Delete(gone); err != nil {
+		t.Fatal(err)
+	}
+	co.Enqueue("Pod", "delete", gone)
+	if n := len(co.queue.order); n != 2 {
+		t.Fatalf("queued %d events, want one per object", n)
+	}
+
+	full := NewCollector(c, stages)
+	if _, err := full.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	want := dumpGraph(full.Graph.Snapshot())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go co.Process(ctx)
+	deadline := time.Now().Add(5 * time.Second)
+	for got := dumpGraph(co.Graph.Snapshot()); got != want; got = dumpGraph(co.Graph.Snapshot()) {
+		if time.Now().After(deadline) {
+			t.Fatalf("after processing:\n%s\nwant:\n%s", got, want)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// Tainting a node must update the placement edges of the pods already on it.
+func TestNodeTaintRelinksPlacement(t *testing.T) {
+	c := loadFake(t, "topology.yaml")
+	co := NewCollector(c, []Stage{TopologyStage})
+	if _, err := co.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	n, ok := co.Graph.Lookup("Node", "", "worker-1")
+	if !ok || n.Props["capacity.cpu"] != "16" || n.Props["condition.Ready"] != "True" {
+		t.Fatalf("worker-1 props = %v", n.Props)
+	}
+
+	nodes, _ := c.Nodes(context.Background())
+	var node corev1.Node
+	for _, nd := range nodes {
+		if nd.Name == "worker-1" {
+			node = nd
+		}
+	}
+	node.Spec.Taints = []corev1.Taint{{Key: "node.kubernetes.io/not-ready", Effect: corev1.TaintEffectNoExecute}}
+	if err := c.Upsert(&node); err != nil {
+		t.Fatal(err)
+	}
+	co.ApplyEvent("Node", "update", &node)
+
+	for _, e := range co.Graph.Snapshot().Edges {
+		if e.Kind == RunsOn && e.To == KeyOf("Node", "", "worker-1").ID() {
+			if e.Attrs["untolerated"] != "node.kubernetes.io/not-ready:NoExecute" {
+				t.Fatalf("runs-on attrs = %v", e.Attrs)
+			}
+			return
+		}
+	}
+	t.Fatal("keystone-api-0 lost its runs-on edge")
+}
+
+// Updating a StatefulSet or its claims must keep the Owns edges to its pods
+// (derived from the pods' ownerReferences) next to those to its
+// volumeClaimTemplate claims.
+func TestStatefulSetRelinkKeepsClaims(t *testing.T) {
+	stages := []Stage{OwnershipStage, PVCStage}
+	c := loadFake(t, "pvc.yaml")
+	co := NewCollector(c, stages)
+	if _, err := co.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	want := dumpGraph(co.Graph.Snapshot())
+
+	claim, ok := co.Graph.Lookup("PersistentVolumeClaim", "openstack", "glance-images")
+	if !ok || claim.Props["phase"] != "Pending" || claim.Props["request.storage"] != "100Gi" ||
+		claim.Props["accessModes"] != "ReadWriteMany" || claim.Props["storageClassName"] != "general" {
+		t.Fatalf("glance-images props = %v", claim.Props)
+	}
+
+	sets, _ := c.StatefulSets(context.Background())
+	co.ApplyEvent("StatefulSet", "update", &sets[0])
+	if got := dumpGraph(co.Graph.Snapshot()); got != want {
+		t.Fatalf("after update:\n%s\nwant:\n%s", got, want)
+	}
+
+	// the retention policy's ownerReference on the claim must not replace
+	// the template edge
+	if !strings.Contains(want, "-owns-> PersistentVolumeClaim/openstack/mysql-data-mariadb-server-0 {ordinal=0 volumeClaimTemplate=mysql-data}") {
+		t.Fatalf("no template edge in:\n%s", want)
+	}
+	pvcs, _ := c.PVCs(context.Background())
+	for i := range pvcs {
+		co.ApplyEvent("PersistentVolumeClaim", "update", &pvcs[i])
+	}
+	if got := dumpGraph(co.Graph.Snapshot()); got != want {
+		t.Fatalf("after claim update:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+// Services without a ready endpoint are flagged, and the flag follows
+// EndpointSlice updates without being lost on Service updates. ExternalName
+// Services are never flagged.
+func TestServiceReadinessFlag(t *testing.T) {
+	c := loadFake(t, "endpoint.yaml")
+	co := NewCollector(c, []Stage{EndpointStage})
+	if _, err := co.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	check := func(svc, ready, none string) {
+		t.Helper()
+		n, ok := co.Graph.Lookup("Service", "openstack", svc)
+		if !ok || n.Props["readyEndpoints"] != ready || n.Props["noReadyEndpoints"] != none {
+			t.Fatalf("%s props = %v, want readyEndpoints=%s noReadyEndpoints=%s", svc, n.Props, ready, none)
+		}
+	}
+	check("nova-api", "1", "false")
+	check("placement-api", "0", "true")
+	check("keystone-external", "", "")
+
+	slices, _ := c.EndpointSlices(context.Background())
+	for i := range slices {
+		es := &slices[i]
+		if es.Name != "placement-api-x7k2p" {
+			continue
+		}
+		ready := true
+		es.Endpoints[0].Conditions.Ready = &ready
+		if err := c.Upsert(es); err != nil {
+			t.Fatal(err)
+		}
+		co.ApplyEvent("EndpointSlice", "update", es)
+	}
+	check("placement-api", "1", "false")
+
+	services, _ := c.Services(context.Background())
+	for i := range services {
+		co.ApplyEvent("Service", "update", &services[i])
+		if services[i].Spec.Type == corev1.ServiceTypeExternalName {
+			// DNS 이름만 있는 Service는 endpoint가 없어도 정상
+			check(services[i].Name, "", "")
+			continue
+		}
+		check(services[i].Name, "1", "false")
+	}
+}
+
+// Release nodes follow the newest release Secret, fall back to the chart
+// label of their members, and never copy the release payload.
+func TestHelmReleaseProps(t *testing.T) {
+	c := loadFake(t, "helm.yaml")
+	co := NewCollector(c, []Stage{HelmStage})
+	if _, err := co.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	check := func(ns, name string, want map[string]string) {
+		t.Helper()
+		n, ok := co.Graph.Lookup("HelmRelease", ns, name)
+		if !ok {
+			t.Fatalf("release %s/%s missing", ns, name)
+		}
+		for _, k := range helmProps {
+			if n.Props[k] != want[k] {
+				t.Fatalf("%s props = %v, want %v", name, n.Props, want)
+			}
+		}
+	}
+	check("openstack", "nova", map[string]string{
+		"chart": "nova", "chartVersion": "0.3.12", "appVersion": "2024.1", "revision": "2", "status": "deployed",
+	})
+	check("kube-system", "ingress-nginx", map[string]string{"chart": "ingress-nginx", "chartVersion": "4.10.1"})
+	for _, n := range co.Graph.Snapshot().Nodes {
+		for k, v := range n.Props {
+			if strings.Contains(v, "password") || strings.HasPrefix(k, "data") {
+				t.Fatalf("%s leaks secret data: %s=%s", n.UID, k, v)
+			}
+		}
+	}
+
+	// helm upgrade가 실패해 v3이 생겼다가 rollback으로 지워지는 경우
+	secrets, _ := c.Secrets(context.Background())
+	var v3 *corev1.Secret
+	for i := range secrets {
+		if secrets[i].Name == "sh.helm.release.v1.nova.v2" {
+			v3 = secrets[i].DeepCopy()
+		}
+	}
+	v3.Name = "sh.helm.release.v1.nova.v3"
+	v3.Labels = map[string]string{"name": "nova", "owner": "helm", "status": "failed", "version": "3"}
+	if err := c.Upsert(v3); err != nil {
+		t.Fatal(err)
+	}
+	co.ApplyEvent("Secret", "add", v3)
+	check("openstack", "nova", map[string]string{
+		"chart": "nova", "chartVersion": "0.3.12", "appVersion": "2024.1", "revision": "3", "status": "failed",
+	})
+	if err := c.Delete(v3); err != nil {
+		t.Fatal(err)
+	}
+	co.ApplyEvent("Secret", "delete", v3)
+	check("openstack", "nova", map[string]string{
+		"chart": "nova", "chartVersion": "0.3.12", "appVersion": "2024.1", "revision": "2", "status": "deployed",
+	})
+
+	// helm uninstall은 소속 객체와 릴리스 Secret을 모두 지우므로 노드도 사라져야 함
+	ctx := context.Background()
+	deps, _ := c.Deployments(ctx)
+	svcs, _ := c.Services(ctx)
+	cms, _ := c.ConfigMaps(ctx)
+	secrets, _ = c.Secrets(ctx)
+	type event struct {
+		kind string
+		obj  interface {
+			metav1.Object
+			runtime.Object
+		}
+	}
+	var events []event
+	for i := range deps {
+		events = append(events, event{"Deployment", &deps[i]})
+	}
+	for i := range svcs {
+		events = append(events, event{"Service", &svcs[i]})
+	}
+	for i := range cms {
+		events = append(events, event{"ConfigMap", &cms[i]})
+	}
+	for i := range secrets {
+		events = append(events, event{"Secret", &secrets[i]})
+	}
+	var nova []event
+	for _, ev := range events {
+		if key, ok := releaseOf(ev.obj); (ok && key.Name == "nova") || ev.obj.GetLabels()["name"] == "nova" {
+			nova = append(nova, ev)
+		}
+	}
+	for i, ev := range nova {
+		if _, ok := co.Graph.Lookup("HelmRelease", "openstack", "nova"); !ok {
+			t.Fatalf("release removed with %d of %d objects left", len(nova)-i, len(nova))
+		}
+		if err := c.Delete(ev.obj); err != nil {
+			t.Fatal(err)
+		}
+		co.ApplyEvent(ev.kind, "delete", ev.obj)
+	}
+	if n, ok := co.Graph.Lookup("HelmRelease", "openstack", "nova"); ok {
+		t.Fatalf("uninstalled release left: %v", n.Props)
+	}
+	check("kube-system", "ingress-nginx", map[string]string{"chart": "ingress-nginx", "chartVersion": "4.10.1"})
+}
+
+// The Keystone catalog links services to the Ingress serving their public
+// host and the Service named by their cluster-internal host, whether it is
+// read from a file or from Keystone.
+func TestKeystoneCatalogStage(t *testing.T) {
+	path := filepath.Join("testdata", "keystone-catalog.json")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("OS_TOKEN", "gAAAAAB-test")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/v3/auth/catalog" || req.Header.Get("X-Auth-Token") != "gAAAAAB-test" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		w.Write(data)
+	}))
+	defer srv.Close()
+
+	want := []string{
+		"edge OpenStackService.openstack.org/compute -exposes-> Ingress.networking.k8s.io/openstack/nova {interface=public region=RegionOne url=https://nova.openstack.example.com/v2.1}",
+		"edge OpenStackService.openstack.org/compute -exposes-> Service/openstack/nova-api {interface=internal region=RegionOne url=http://nova-api.openstack.svc.cluster.local:8774/v2.1}",
+		"edge OpenStackService.openstack.org/image -exposes-> Service/openstack/glance-api {interface=internal region=RegionOne url=http://glance-api.openstack:9292}",
+	}
+	for _, source := range []string{path, srv.URL + "/v3/auth/catalog"} {
+		c := loadFake(t, "openstack.yaml")
+		g := NewGraph()
+		for _, stage := range []Stage{OpenStackStage, KeystoneCatalogStage(source)} {
+			if err := stage(context.Background(), c, g); err != nil {
+				t.Fatalf("%s: %v", source, err)
+			}
+		}
+		var got []string
+		for _, line := range strings.Split(dumpGraph(g.Snapshot()), "\n") {
+			if strings.Contains(line, "-exposes->") {
+				got = append(got, line)
+			}
+		}
+		if strings.Join(got, "\n") != strings.Join(want, "\n") {
+			t.Fatalf("%s: exposes edges:\n%s\nwant:\n%s", source, strings.Join(got, "\n"), strings.Join(want, "\n"))
+		}
+		// cinder의 서비스 노드는 카탈로그 type(volumev3)이 아니라 정규화된 이름으로
+		if _, ok := g.Lookup("OpenStackService", "", "block-storage"); !ok {
+			t.Fatalf("%s: block-storage service missing", source)
+		}
+	}
+
+	// Keystone 장애는 기본 abort 정책에서도 수집을 멈추지 않고 degraded로만 남김
+	t.Setenv("OS_TOKEN", "expired")
+	co := NewCollector(loadFake(t, "openstack.yaml"), []Stage{OpenStackStage, KeystoneCatalogStage(srv.URL + "/v3/auth/catalog")})
+	res, err := co.Run(context.Background())
+	if err != nil {
+		t.Fatalf("rejected token aborted the run: %v", err)
+	}
+	if kinds := res.FailedKinds(); len(kinds) != 1 || kinds[0] != "KeystoneCatalog" {
+		t.Fatalf("failed kinds = %v, want [KeystoneCatalog]", kinds)
+	}
+	if stage := res.Failures[0].Stage; stage != "KeystoneCatalogStage" {
+		t.Fatalf("failed stage = %q, want KeystoneCatalogStage", stage)
+	}
+	if _, ok := co.Graph.Lookup("OpenStackService", "", "compute"); !ok {
+		t.Fatal("graph without the catalog lost the OpenStack services")
+	}
+
+	// 응답하지 않는 Keystone도 시간 제한 후 degraded로 남김
+	hung := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		<-req.Context().Done()
+	}))
+	defer hung.Close()
+	defer func(d time.Duration) { keystoneTimeout = d }(keystoneTimeout)
+	keystoneTimeout = 50 * time.Millisecond
+	co = NewCollector(loadFake(t, "openstack.yaml"), []Stage{OpenStackStage, KeystoneCatalogStage(hung.URL + "/v3/auth/catalog")})
+	res, err = co.Run(context.Background())
+	if err != nil {
+		t.Fatalf("hung Keystone aborted the run: %v", err)
+	}
+	if kinds := res.FailedKinds(); len(kinds) != 1 || kinds[0] != "KeystoneCatalog" {
+		t.Fatalf("failed kinds = %v, want [KeystoneCatalog]", kinds)
+	}
+}
+
+// Dependencies follow config updates like a full re-collect, and no part of
+// a connection URL's credentials ends up in the graph.
+func TestDependencyRelink(t *testing.T) {
+	stages := []Stage{ConfigSecretStage, DependencyStage}
+	c := loadFake(t, "dependency.yaml")
+	co := NewCollector(c, stages)
+	if _, err := co.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	fullRun := func() string {
+		full := NewCollector(c, stages)
+		if _, err := full.Run(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		return dumpGraph(full.Graph.Snapshot())
+	}
+	s := co.Graph.Snapshot()
+	for _, secret := range []string{"s3cr3t", "Sup3r", "rootpw", "pa@ss", "nova_api", "c1nd"} {
+		if dump := dumpGraph(s); strings.Contains(dump, secret) {
+			t.Fatalf("graph contains %q:\n%s", secret, dump)
+		}
+		for _, n := range s.Nodes {
+			for k, v := range n.Props {
+				if strings.Contains(v, secret) {
+					t.Fatalf("%s prop %s contains %q", n.UID, k, secret)
+				}
+			}
+		}
+	}
+
+	// RabbitMQ 설정이 빠지면 해당 edge도 사라져야 함
+	secrets, _ := c.Secrets(context.Background())
+	for i := range secrets {
+		sec := &secrets[i]
+		if sec.Name != "nova-etc" {
+			continue
+		}
+		var kept []string
+		for _, line := range strings.Split(string(sec.Data["nova.conf"]), "\n") {
+			if !strings.HasPrefix(line, "transport_url") {
+				kept = append(kept, line)
+			}
+		}
+		sec.Data["nova.conf"] = []byte(strings.Join(kept, "\n"))
+		if err := c.Upsert(sec); err != nil {
+			t.Fatal(err)
+		}
+		co.ApplyEvent("Secret", "update", sec)
+	}
+	got, want := dumpGraph(co.Graph.Snapshot()), fullRun()
+	if got != want {
+		t.Fatalf("after secret update:\n%s\nwant:\n%s", got, want)
+	}
+	if strings.Contains(got, "nova-api-osapi-7d9f8-abcde -depends-on-> Service/openstack/rabbitmq") {
+		t.Fatal("rabbitmq dependency kept after transport_url was removed")
+	}
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "openstack", Name: "cinder-scheduler-6f8b9-zxcvb"},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{
+			Name: "cinder-scheduler", Image: "cinder:2024.1",
+			EnvFrom: []corev1.EnvFromSource{{ConfigMapRef: &corev1.ConfigMapEnvSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "cinder-env"},
+			}}},
+		}}},
+	}
+	if err := c.Upsert(pod); err != nil {
+		t.Fatal(err)
+	}
+	co.ApplyEvent("Pod", "add", pod)
+	// Pod 이벤트는 이 스테이지 밖의 relation(serviceaccount 등)도 갱신하므로 depends-on만 비교
+	dependsOn := func(dump string) string {
+		var lines []string
+		for _, line := range strings.Split(dump, "\n") {
+			if strings.Contains(line, "-depends-on->") {
+				lines = append(lines, line)
+			}
+		}
+		return strings.Join(lines, "\n")
+	}
+	if got, want := dependsOn(dumpGraph(co.Graph.Snapshot())), dependsOn(fullRun()); got != want {
+		t.Fatalf("after pod add:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+// Pods initializing on unready dependencies are flagged; the flag follows
+// the dependencies and the pod's own Initialized condition.
+func TestInitWaitFlags(t *testing.T) {
+	c := loadFake(t, "entrypoint.yaml")
+	co := NewCollector(c, []Stage{EntrypointStage})
+	if _, err := co.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	check := func(pod, stuck, waiting string) {
+		t.Helper()
+		n, ok := co.Graph.Lookup("Pod", "openstack", pod)
+		if !ok || n.Props["stuckInInit"] != stuck || n.Props["waitingFor"] != waiting {
+			t.Fatalf("%s props = %v, want stuckInInit=%s waitingFor=%s", pod, n.Props, stuck, waiting)
+		}
+	}
+	check("nova-api-osapi-7d9f8-abcde", "true", "Job.batch/openstack/nova-storage-init,Service/openstack/keystone-api")
+	check("nova-compute-default-x7k2p", "false", "")
+	check("nova-cell-setup-29169600-qbmkj", "false", "")
+	check("libvirt-libvirt-default-aaaaa", "", "")
+
+	jobs, _ := c.Jobs(context.Background())
+	for i := range jobs {
+		if jobs[i].Name == "nova-storage-init" {
+			jobs[i].Status.Active, jobs[i].Status.Succeeded = 0, 1
+			if err := c.Upsert(&jobs[i]); err != nil {
+				t.Fatal(err)
+			}
+			co.ApplyEvent("Job", "update", &jobs[i])
+		}
+	}
+	check("nova-api-osapi-7d9f8-abcde", "true", "Service/openstack/keystone-api")
+
+	// init 컨테이너가 끝나면 대기 중인 의존성이 남아 있어도 stuck이 아님
+	pods, _ := c.Pods(context.Background())
+	for i := range pods {
+		if pods[i].Name == "nova-api-osapi-7d9f8-abcde" {
+			pods[i].Status.Conditions[0].Status = corev1.ConditionTrue
+			if err := c.Upsert(&pods[i]); err != nil {
+				t.Fatal(err)
+			}
+			co.ApplyEvent("Pod", "update", &pods[i])
+		}
+	}
+	check("nova-api-osapi-7d9f8-abcde", "false", "Service/openstack/keystone-api")
+
+	// EndpointSlice와 DaemonSet pod 이벤트도 ready를 다시 계산
+	ctx := context.Background()
+	slices, _ := c.EndpointSlices(ctx)
+	for i := range slices {
+		if slices[i].Name == "keystone-api-fghij" {
+			ready := true
+			slices[i].Endpoints[0].Conditions.Ready = &ready
+			if err := c.Upsert(&slices[i]); err != nil {
+				t.Fatal(err)
+			}
+			co.ApplyEvent("EndpointSlice", "update", &slices[i])
+			check("nova-api-osapi-7d9f8-abcde", "false", "")
+
+			if err := c.Delete(&slices[i]); err != nil {
+				t.Fatal(err)
+			}
+			co.ApplyEvent("EndpointSlice", "delete", &slices[i])
+			check("nova-api-osapi-7d9f8-abcde", "false", "Service/openstack/keystone-api")
+		}
+	}
+	pods, _ = c.Pods(ctx)
+	for i := range pods {
+		if pods[i].Name == "libvirt-libvirt-default-aaaaa" {
+			pods[i].Status.Conditions[0].Status = corev1.ConditionFalse
+			if err := c.Upsert(&pods[i]); err != nil {
+				t.Fatal(err)
+			}
+			co.ApplyEvent("Pod", "update", &pods[i])
+		}
+	}
+	check("nova-compute-default-x7k2p", "false", "DaemonSet.apps/openstack/libvirt-libvirt-default")
+}
+
+// Gateway API objects are unstructured; route updates must still relink
+// like a full run.
+func TestGatewayRouteRelink(t *testing.T) {
+	c := loadFake(t, "gateway.yaml")
+	co := NewCollector(c, []Stage{GatewayStage})
+	if _, err := co.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if gw, ok := co.Graph.Lookup("Gateway", "gateway-infra", "public"); !ok || gw.Props["addresses"] != "203.0.113.10" {
+		t.Fatalf("gateway props = %v", gw.Props)
+	}
+
+	routes, err := c.Objects(context.Background(), GatewayAPIKinds["HTTPRoute"])
+	if err != nil || len(routes) != 1 {
+		t.Fatalf("HTTPRoutes = %d, %v", len(routes), err)
+	}
+	route := &routes[0]
+	rules, _, _ := unstructured.NestedSlice(route.Object, "spec", "rules")
+	if err := unstructured.SetNestedSlice(route.Object, rules[:1], "spec", "rules"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Upsert(route); err != nil {
+		t.Fatal(err)
+	}
+	co.ApplyEvent("HTTPRoute", "update", route)
+
+	full := NewCollector(c, []Stage{GatewayStage})
+	if _, err := full.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	// 참조로만 생긴 grafana 노드는 남으므로 edge만 비교
+	edges := func(s *Snapshot) string {
+		var out []string
+		for _, line := range strings.Split(dumpGraph(s), "\n") {
+			if strings.HasPrefix(line, "edge ") {
+				out = append(out, line)
+			}
+		}
+		return strings.Join(out, "\n")
+	}
+	if got, want := edges(co.Graph.Snapshot()), edges(full.Graph.Snapshot()); got != want {
+		t.Fatalf("after update:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+// Deleting or adding a ReferenceGrant updates the edges that cross into
+// its namespace without touching the Gateways or routes themselves.
+func TestReferenceGrantRelink(t *testing.T) {
+	c := loadFake(t, "gateway.yaml")
+	co := NewCollector(c, []Stage{GatewayStage})
+	if _, err := co.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	gw := KeyOf("Gateway", "gateway-infra", "public").ID()
+	secret := KeyOf("Secret", "openstack", "identity-tls").ID()
+	check := func(grant, unresolved string) {
+		t.Helper()
+		for _, e := range co.Graph.Incident(secret) {
+			if e.From == gw && e.Kind == Reads {
+				if e.Attrs["referenceGrant"] != grant || e.Attrs["unresolved"] != unresolved {
+					t.Fatalf("reads attrs = %v, want referenceGrant=%q unresolved=%q", e.Attrs, grant, unresolved)
+				}
+				return
+			}
+		}
+		t.Fatalf("no %s -reads-> %s edge", gw, secret)
+	}
+	check("openstack/gateway-certs", "")
+
+	grants, err := c.Objects(context.Background(), GatewayAPIKinds["ReferenceGrant"])
+	if err != nil || len(grants) != 1 {
+		t.Fatalf("ReferenceGrants = %d, %v", len(grants), err)
+	}
+	grant := grants[0].DeepCopy()
+	if err := c.Delete(grant); err != nil {
+		t.Fatal(err)
+	}
+	co.ApplyEvent("ReferenceGrant", "delete", grant)
+	check("", "RefNotPermitted")
+
+	grant.SetResourceVersion("")
+	if err := c.Upsert(grant); err != nil {
+		t.Fatal(err)
+	}
+	co.ApplyEvent("ReferenceGrant", "add", grant)
+	check("openstack/gateway-certs", "")
+}
+
+// CRDStage collects the configured custom resources, turns rule fields into
+// edges and keeps them up to date on events.
+func TestCRDStage(t *testing.T) {
+	cfg, err := LoadCRDConfig(filepath.Join("testdata", "crd-config.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stage, err := CRDStage(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := loadFake(t, "crd.yaml")
+	co := NewCollector(c, []Stage{stage})
+	if _, err := co.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "crd.golden", dumpGraph(co.Graph.Snapshot()))
+
+	gvk := cfg.Kinds()["MariaDB"]
+	dbs, err := c.Objects(context.Background(), gvk)
+	if err != nil || len(dbs) != 1 {
+		t.Fatalf("MariaDBs = %d, %v", len(dbs), err)
+	}
+	db := &dbs[0]
+	if err := unstructured.SetNestedField(db.Object, "mariadb-root-v2", "spec", "rootPasswordSecretKeyRef", "name"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Upsert(db); err != nil {
+		t.Fatal(err)
+	}
+	co.ApplyEvent("MariaDB", "update", db)
+	got := dumpGraph(co.Graph.Snapshot())
+	if !strings.Contains(got, "-reads-> Secret/openstack/mariadb-root-v2") ||
+		strings.Contains(got, "-reads-> Secret/openstack/mariadb-root {") {
+		t.Fatalf("after update:\n%s", got)
+	}
+}
+
+// The kind registry is shared: the same config registers again, a config
+// with other rules for a registered kind is refused.
+func TestCRDStageReregister(t *testing.T) {
+	cfg, err := LoadCRDConfig(filepath.Join("testdata", "crd-config.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := CRDStage(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := CRDStage(cfg); err != nil {
+		t.Fatalf("same config: %v", err)
+	}
+	other := *cfg
+	other.Rules = append([]FieldRule{}, cfg.Rules...)
+	other.Rules[0].Target = "ConfigMap"
+	if _, err := CRDStage(&other); err == nil {
+		t.Fatalf("rule for %s changed: want error", other.Rules[0].Kind)
+	}
+	builtin := &CRDConfig{Resources: []CustomResource{{Group: "example.com", Version: "v1", Kind: "Service"}}}
+	if _, err := CRDStage(builtin); err == nil {
+		t.Fatal("Service in example.com: want error")
+	}
+}
+
+// A `kubectl cluster-info dump` of the workload fixture must produce the
+// same graph as the manifest itself.
+func TestDumpBuildsSameGraph(t *testing.T) {
+	dump, err := k8sclient.NewFromDump(filepath.Join("testdata", "dump"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stages := []Stage{WorkloadStage, ServiceAccountStage}
+	run := func(c Client) string {
+		co := NewCollector(c, stages)
+		if _, err := co.Run(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		return dumpGraph(co.Graph.Snapshot())
+	}
+	if got, want := run(dump), run(loadFake(t, "workload.yaml")); got != want {
+		t.Fatalf("dump graph:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func loadFake(t *testing.T, name string) *k8sclient.Fake {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := k8sclient.NewFakeFromYAML(data)
+	if err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+	return c
+}
+
+func checkGolden(t *testing.T, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run with -update to create it)", err)
+	}
+	if got != string(want) {
+		t.Errorf("%s mismatch:\n--- got\n%s--- want\n%s", name, got, want)
+	}
+}
+
+// dumpGraph renders a snapshot as sorted node and edge lines. Node
+// properties are left out; see TestObjectProps.
+func dumpGraph(s *Snapshot) string {
+	var lines []string
+	for _, n := range s.Nodes {
+		lines = append(lines, "node "+n.UID)
+	}
+	for _, e := range s.Edges {
+		line := "edge " + e.From + " -" + string(e.Kind) + "-> " + e.To
+		if len(e.Attrs) > 0 {
+			kv := make([]string, 0, len(e.Attrs))
+			for k, v := range e.Attrs {
+				kv = append(kv, k+"="+v)
+			}
+			sort.Strings(kv)
+			line += " {" + strings.Join(kv, " ") + "}"
+		}
+		lines = append(lines, line)
+	}
+	sort.Strings(lines)
+	return strings.Join(lines, "\n") + "\n"
+}
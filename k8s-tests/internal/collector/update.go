@@ -1,9 +1,17 @@
 package collector
 
 import (
+	"context"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"time"
 	"fmt"
+	"log"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	discv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
 func timestamp() string {
@@ -26,4 +34,135 @@ func HandleUpdate(g *Graph, kind string, obj *unstructured.Unstructured) {
 func HandleDelete(g *Graph, kind string, obj *unstructured.Unstructured) {
 	fmt.Printf("[%s] [HandleDelete] kind=%s name=%s\n", timestamp(), kind, obj.GetName())
 	g.DeleteResource(kind, obj)
-}
\ No newline at end of file
+}
+
+// Relink recomputes the edges around obj after an add, update or delete
+// event. Relations of obj's kind are re-derived from obj itself; relations
+// that can point at obj's kind are re-derived for the source objects near
+// obj (see relation.near) and filtered to the edges touching obj, or the
+// nodes it stands for (see relation.ends). The
+// result replaces the previous edges of those relations in one step, and
+// the derived properties of the nodes whose edges changed are recomputed.
+// linked lists the nodes a deleted obj was linked to. If a kind cannot be
+// listed the graph is left untouched, keeping the previous edges rather
+// than dropping them.
+func Relink(ctx context.Context, c Client, g *Graph, kind string, obj *unstructured.Unstructured, deleted bool, linked []string) error {
+	return newResolver(ctx, c, g).relink(kind, obj, deleted, linked)
+}
+
+// relink is Relink with the listings of r, so that the events of one batch
+// list each kind once. Only the failures of this call are reported.
+func (r *resolver) relink(kind string, obj *unstructured.Unstructured, deleted bool, linked []string) error {
+	uid := KeyOf(kind, obj.GetNamespace(), obj.GetName()).ID()
+	g := r.g
+	r.errs = nil
+
+	var drops []func(Edge) bool
+	var add []Edge
+	scope := []string{uid}
+	for _, rel := range allRelations() {
+		if rel.kind == kind {
+			typed, err := rel.decode(obj)
+			if err != nil {
+				log.Printf("[Relink] decode %s %s/%s: %v", kind, obj.GetNamespace(), obj.GetName(), err)
+				continue
+			}
+			drops = append(drops, rel.ownedBy(uid, typed))
+			if rel.scope != nil {
+				// 소유 edge가 이 노드에 붙어 있지 않을 수 있음
+				scope = append(scope, rel.scope(r, typed)...)
+			}
+			if !deleted {
+				add = append(add, rel.derive(r, typed)...)
+			}
+		}
+		if rel.targetsKind(kind) {
+			ends := map[string]bool{uid: true}
+			if rel.ends != nil {
+				ends = map[string]bool{}
+				for _, end := range rel.ends(kind, obj) {
+					ends[end] = true
+					scope = append(scope, end)
+				}
+			}
+			// 삭제된 노드의 edge는 이미 사라졌고 다른 노드의 edge만 다시 계산
+			if deleted {
+				delete(ends, uid)
+			}
+			if len(ends) == 0 {
+				continue
+			}
+			drops = append(drops, func(e Edge) bool {
+				return (ends[e.From] || ends[e.To]) && hasEdgeKind(rel.edges, e.Kind)
+			})
+			rel.each(r, func(src metav1.Object) {
+				if rel.near != nil && !rel.near(kind, obj, src) {
+					return
+				}
+				for _, e := range rel.derive(r, src) {
+					if ends[e.From] || ends[e.To] {
+						add = append(add, e)
+					}
+				}
+			})
+		}
+	}
+	if err := r.err(); err != nil {
+		return err
+	}
+	if kind == "ReferenceGrant" {
+		// grant는 edge가 없고 참조하는 쪽 edge의 속성만 바꿈
+		return r.relinkGrantReferrers(obj.GetNamespace())
+	}
+	if len(drops) == 0 {
+		return nil
+	}
+	touched := g.Relink(scope, func(e Edge) bool {
+		for _, d := range drops {
+			if d(e) {
+				return true
+			}
+		}
+		return false
+	}, add)
+	touched = append(append(touched, uid), linked...)
+	switch kind {
+	case "Service":
+		markServiceReadiness(g, []string{uid})
+	case "EndpointSlice":
+		// 슬라이스 이벤트는 kubernetes.io/service-name 라벨의 Service만 바꿈
+		if svc := obj.GetLabels()[discv1.LabelServiceName]; svc != "" {
+			markServiceReadiness(g, []string{KeyOf("Service", obj.GetNamespace(), svc).ID()})
+		}
+	}
+	// 릴리스 Secret이나 소속 객체가 바뀌면 그 릴리스만 다시 계산. 소속이
+	// 바뀌거나 지워진 객체의 이전 릴리스는 touched에 들어 있음
+	if release, ok := releaseNamed(kind, obj); ok {
+		if kind == "Secret" && !deleted {
+			var s corev1.Secret
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &s); err == nil {
+				markReleaseSecret(g, &s)
+			}
+		}
+		touched = append(touched, release.ID())
+	}
+	markHelmReleases(g, touched)
+	switch kind {
+	case "Pod", "Service", "EndpointSlice", "Job", "DaemonSet":
+		markInitWaits(g, touched)
+	}
+	return nil
+}
+
+// nodesOf returns the UIDs of every node of kind, for the batch stages
+// that set derived properties on all of them.
+func nodesOf(g *Graph, kind string) []string {
+	var out []string
+	for uid, n := range g.Snapshot().Nodes {
+		if n.Type == kind {
+			out = append(out, uid)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
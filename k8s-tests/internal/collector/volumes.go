@@ -0,0 +1,166 @@
+package collector
+
+import (
+	"strconv"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Storage edges besides PVC -binds-> PV -uses-> StorageClass:
+//
+//	Pod -mounts-> PersistentVolumeClaim {container, mountPath, readOnly}
+//	PersistentVolumeClaim -uses-> StorageClass (the class the claim asks for)
+//	StatefulSet -owns-> PersistentVolumeClaim {volumeClaimTemplate, ordinal}
+//	VolumeAttachment -attaches-> PersistentVolume {attacher, attached}
+//	VolumeAttachment -attached-to-> Node (only while status.attached)
+//
+// Claims are nodes whatever their phase, so a Pending claim shows up with
+// the pods waiting on it and the class that should provision it.
+
+// derivePodClaims links a pod to the claims of its persistentVolumeClaim
+// volumes and to the claims generic ephemeral volumes create, which are
+// named <pod>-<volume>.
+func derivePodClaims(r *resolver, pod *corev1.Pod) []Edge {
+	podUID := r.node(pod.Namespace, pod.Name, "Pod")
+	containers := podContainers(pod)
+	var out []Edge
+	for _, vol := range pod.Spec.Volumes {
+		var claim string
+		readOnly := false
+		switch {
+		case vol.PersistentVolumeClaim != nil:
+			claim, readOnly = vol.PersistentVolumeClaim.ClaimName, vol.PersistentVolumeClaim.ReadOnly
+		case vol.Ephemeral != nil:
+			claim = pod.Name + "-" + vol.Name
+		default:
+			continue
+		}
+		to := r.node(pod.Namespace, claim, "PersistentVolumeClaim")
+		for _, attrs := range volumeMountAttrs(containers, vol.Name, nil, nil) {
+			// key와 optional은 ConfigMap/Secret 볼륨에만 의미가 있음
+			delete(attrs, "key")
+			delete(attrs, "optional")
+			if readOnly {
+				attrs["readOnly"] = "true"
+			}
+			out = append(out, Edge{From: podUID, To: to, Kind: Mounts, Attrs: attrs})
+		}
+	}
+	return out
+}
+
+func derivePVCStorageClass(r *resolver, pvc *corev1.PersistentVolumeClaim) []Edge {
+	if pvc.Spec.StorageClassName == nil || *pvc.Spec.StorageClassName == "" {
+		return nil
+	}
+	return []Edge{{
+		From: r.node(pvc.Namespace, pvc.Name, "PersistentVolumeClaim"),
+		To:   r.node("", *pvc.Spec.StorageClassName, "StorageClass"),
+		Kind: Uses,
+	}}
+}
+
+// claimTemplateRelation derives StatefulSet -owns-> PVC edges from
+// volumeClaimTemplates. The controller names the claims
+// <template>-<statefulset>-<ordinal> and only sets ownerReferences on them
+// under a persistentVolumeClaimRetentionPolicy, so the edges are matched by
+// name. Claims left behind by a scale-down are included. The relation owns
+// only the edges it marks with volumeClaimTemplate: the StatefulSet's other
+// Owns edges come from its pods' ownerReferences, while the claims' own
+// reference to it is left to this relation (see claimTemplateOwner).
+func claimTemplateRelation() *relation {
+	rel := newRelation("statefulset-claims", "StatefulSet",
+		[]EdgeKind{Owns}, []string{"PersistentVolumeClaim"}, (*resolver).statefulSets, deriveClaimTemplates)
+	rel.owned = func(e Edge, obj metav1.Object) bool {
+		return e.Kind == Owns && e.Attrs["volumeClaimTemplate"] != "" &&
+			e.From == KeyOf("StatefulSet", obj.GetNamespace(), obj.GetName()).ID()
+	}
+	return namespaced(rel)
+}
+
+func deriveClaimTemplates(r *resolver, sts *appsv1.StatefulSet) []Edge {
+	if len(sts.Spec.VolumeClaimTemplates) == 0 {
+		return nil
+	}
+	from := r.node(sts.Namespace, sts.Name, "StatefulSet")
+	var out []Edge
+	for _, pvc := range r.pvcs() {
+		if pvc.Namespace != sts.Namespace {
+			continue
+		}
+		for _, tpl := range sts.Spec.VolumeClaimTemplates {
+			ordinal, ok := claimOrdinal(pvc.Name, tpl.Name, sts.Name)
+			if !ok {
+				continue
+			}
+			out = append(out, Edge{
+				From:  from,
+				To:    r.node(pvc.Namespace, pvc.Name, "PersistentVolumeClaim"),
+				Kind:  Owns,
+				Attrs: map[string]string{"volumeClaimTemplate": tpl.Name, "ordinal": ordinal},
+			})
+		}
+	}
+	return out
+}
+
+// claimOrdinal returns the ordinal of claim if it is named after template
+// tpl of StatefulSet sts.
+func claimOrdinal(claim, tpl, sts string) (string, bool) {
+	ordinal, ok := strings.CutPrefix(claim, tpl+"-"+sts+"-")
+	if !ok {
+		return "", false
+	}
+	if _, err := strconv.ParseUint(ordinal, 10, 32); err != nil {
+		return "", false
+	}
+	return ordinal, true
+}
+
+// claimTemplateOwner reports whether ref, an ownerReference of claim, is
+// the StatefulSet whose volumeClaimTemplates the claim was created from.
+// That edge belongs to claimTemplateRelation, which marks it with the
+// template; owners leaves it out so the two do not overwrite each other.
+func (r *resolver) claimTemplateOwner(claim metav1.Object, ref metav1.OwnerReference) bool {
+	if ref.Kind != "StatefulSet" {
+		return false
+	}
+	for _, sts := range r.statefulSets() {
+		if sts.Namespace != claim.GetNamespace() || sts.Name != ref.Name {
+			continue
+		}
+		for _, tpl := range sts.Spec.VolumeClaimTemplates {
+			if _, ok := claimOrdinal(claim.GetName(), tpl.Name, sts.Name); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// deriveVolumeAttachment links an attachment to its persistent volume and,
+// once the attacher reports it attached, to the node. Inline CSI volumes
+// (spec.source.inlineVolumeSpec) have no PersistentVolume object and only
+// get the node edge.
+func deriveVolumeAttachment(r *resolver, va *storagev1.VolumeAttachment) []Edge {
+	from := r.node("", va.Name, "VolumeAttachment")
+	attrs := map[string]string{
+		"attacher": va.Spec.Attacher,
+		"attached": strconv.FormatBool(va.Status.Attached),
+	}
+	if e := va.Status.AttachError; e != nil {
+		attrs["attachError"] = e.Message
+	}
+	var out []Edge
+	if pv := va.Spec.Source.PersistentVolumeName; pv != nil && *pv != "" {
+		out = append(out, Edge{From: from, To: r.node("", *pv, "PersistentVolume"), Kind: Attaches, Attrs: attrs})
+	}
+	if va.Status.Attached && va.Spec.NodeName != "" {
+		out = append(out, Edge{From: from, To: r.node("", va.Spec.NodeName, "Node"), Kind: AttachedTo})
+	}
+	return out
+}
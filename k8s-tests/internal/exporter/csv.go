@@ -0,0 +1,94 @@
+package exporter
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/kaist2025/k8s-e2e-tests/internal/collector"
+)
+
+// WriteNodesCSV writes one row per node. Props is a JSON object so the
+// column set stays fixed regardless of which labels a cluster uses.
+func WriteNodesCSV(g *collector.Snapshot, w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"UID", "Label", "Type", "Group", "NS", "ObjectUID", "Props"}); err != nil {
+		return err
+	}
+	for _, n := range g.Nodes {
+		props, err := json.Marshal(n.Props)
+		if err != nil {
+			return err
+		}
+		if err := cw.Write([]string{n.UID, n.Label, n.Type, n.Group, n.NS, string(n.ObjectUID), string(props)}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteEdgesCSV writes one row per edge with its attributes as JSON.
+func WriteEdgesCSV(g *collector.Snapshot, w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"ID", "FromUID", "ToUID", "Kind", "Attrs"}); err != nil {
+		return err
+	}
+	for id, e := range g.Edges {
+		attrs, err := json.Marshal(e.Attrs)
+		if err != nil {
+			return err
+		}
+		if err := cw.Write([]string{id, e.From, e.To, string(e.Kind), string(attrs)}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// ReadCSV loads a graph saved by WriteNodesCSV and WriteEdgesCSV.
+func ReadCSV(nodes, edges io.Reader) (*collector.Snapshot, error) {
+	nrows, err := readRows(nodes, 7)
+	if err != nil {
+		return nil, fmt.Errorf("nodes: %w", err)
+	}
+	ns := make([]collector.Node, 0, len(nrows))
+	for _, r := range nrows {
+		n := collector.Node{UID: r[0], Label: r[1], Type: r[2], Group: r[3], NS: r[4], ObjectUID: types.UID(r[5])}
+		if err := json.Unmarshal([]byte(r[6]), &n.Props); err != nil {
+			return nil, fmt.Errorf("nodes: %s: %w", r[0], err)
+		}
+		ns = append(ns, n)
+	}
+	erows, err := readRows(edges, 5)
+	if err != nil {
+		return nil, fmt.Errorf("edges: %w", err)
+	}
+	es := make([]collector.Edge, 0, len(erows))
+	for _, r := range erows {
+		e := collector.Edge{From: r[1], To: r[2], Kind: collector.EdgeKind(r[3])}
+		if err := json.Unmarshal([]byte(r[4]), &e.Attrs); err != nil {
+			return nil, fmt.Errorf("edges: %s: %w", r[0], err)
+		}
+		es = append(es, e)
+	}
+	return collector.NewSnapshot(ns, es), nil
+}
+
+// readRows reads every row after the header, each with n fields.
+func readRows(r io.Reader, n int) ([][]string, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = n
+	rows, err := cr.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	return rows[1:], nil
+}
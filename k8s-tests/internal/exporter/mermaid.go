@@ -2,17 +2,59 @@ package exporter
 
 import (
     "fmt"
+    "hash/fnv"
     "io"
+    "regexp"
+    "sort"
+    "strings"
+
     "github.com/kaist2025/k8s-e2e-tests/internal/collector"
 )
 
-func WriteMermaid(g *collector.Graph, w io.Writer) error {
+var sanitizer = regexp.MustCompile(`[^A-Za-z0-9_-]`)
+
+// MermaidID turns a graph UID into a Mermaid-safe node id. The hash suffix
+// keeps UIDs that sanitize to the same string apart.
+func MermaidID(uid string) string {
+    h := fnv.New32a()
+    h.Write([]byte(uid))
+    return fmt.Sprintf("%s_%08x", sanitizer.ReplaceAllString(uid, "_"), h.Sum32())
+}
+
+func WriteMermaid(g *collector.Snapshot, w io.Writer) error {
     fmt.Fprintln(w, "graph LR")
     for _, n := range g.Nodes {
-        fmt.Fprintf(w, `%s["%s"]:::ns_%s\n`, n.UID, n.Label, n.NS)
+        fmt.Fprintf(w, "%s[\"%s: %s\"]:::ns_%s\n", MermaidID(n.UID), n.Type, n.Label, sanitizer.ReplaceAllString(n.NS, "_"))
+        if len(n.Props) > 0 {
+            fmt.Fprintf(w, "click %s callback \"%s\"\n", MermaidID(n.UID), tooltip(n.Props))
+        }
     }
     for _, e := range g.Edges {
-        fmt.Fprintf(w, "%s -->|%s| %s\n", e.From, e.Kind, e.To)
+        label := string(e.Kind)
+        if len(e.Attrs) > 0 {
+            label += "<br/>" + joinProps(e.Attrs, " ")
+        }
+        fmt.Fprintf(w, "%s -->|\"%s\"| %s\n", MermaidID(e.From), label, MermaidID(e.To))
     }
     return nil
-}
\ No newline at end of file
+}
+
+// tooltip renders node properties as sorted "key=value" lines.
+func tooltip(props map[string]string) string {
+    return joinProps(props, "<br/>")
+}
+
+// joinProps renders props as sorted "key=value" pairs, escaped for use
+// inside a quoted Mermaid string.
+func joinProps(props map[string]string, sep string) string {
+    keys := make([]string, 0, len(props))
+    for k := range props {
+        keys = append(keys, k)
+    }
+    sort.Strings(keys)
+    pairs := make([]string, 0, len(keys))
+    for _, k := range keys {
+        pairs = append(pairs, k+"="+props[k])
+    }
+    return strings.ReplaceAll(strings.Join(pairs, sep), `"`, "#quot;")
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"strings"
 
 	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
 	"github.com/kaist2025/k8s-e2e-tests/internal/collector"
@@ -17,21 +18,31 @@ func ConnectNeo4j(uri, user, password string) neo4j.DriverWithContext {
 	return driver
 }
 
-func ExportToNeo4j(ctx context.Context, g *collector.Graph, driver neo4j.DriverWithContext) error {
+func ExportToNeo4j(ctx context.Context, g *collector.Snapshot, driver neo4j.DriverWithContext) error {
 	session := driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
 	defer session.Close(ctx)
 
 	for _, node := range g.Nodes {
 		_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+			// SET n = $props 로 이전 export의 stale 속성까지 교체
 			query := `
 MERGE (n:Resource {uid: $uid})
-SET n.name = $name, n.type = $type, n.namespace = $ns
+SET n = $props
 `
+			// 고정 필드를 나중에 넣어 같은 이름의 Props가 덮어쓰지 못하게 함
+			props := map[string]any{}
+			for k, v := range node.Props {
+				props[k] = v
+			}
+			props["uid"] = node.UID
+			props["name"] = node.Label
+			props["type"] = node.Type
+			props["group"] = node.Group
+			props["namespace"] = node.NS
+			props["object_uid"] = string(node.ObjectUID)
 			params := map[string]any{
-				"uid":  node.UID,
-				"name": node.Label,
-				"type": node.Type,
-				"ns":   node.NS,
+				"uid":   node.UID,
+				"props": props,
 			}
 			return tx.Run(ctx, query, params)
 		})
@@ -40,15 +51,23 @@ SET n.name = $name, n.type = $type, n.namespace = $ns
 		}
 	}
 
-	for _, edge := range g.Edges {
+	for id, edge := range g.Edges {
 		_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+			// id로 MERGE 해야 같은 노드 쌍 사이의 평행 edge(다른 path/port 등)가 유지됨
 			query := fmt.Sprintf(`
 MATCH (a:Resource {uid: $from}), (b:Resource {uid: $to})
-MERGE (a)-[:%s]->(b)
-`, edge.Kind)
+MERGE (a)-[r:%s {id: $id}]->(b)
+SET r = $attrs
+`, relType(edge.Kind))
+			attrs := map[string]any{"id": id}
+			for k, v := range edge.Attrs {
+				attrs[k] = v
+			}
 			params := map[string]any{
-				"from": edge.From,
-				"to":   edge.To,
+				"from":  edge.From,
+				"to":    edge.To,
+				"id":    id,
+				"attrs": attrs,
 			}
 			return tx.Run(ctx, query, params)
 		})
@@ -59,4 +78,10 @@ MERGE (a)-[:%s]->(b)
 
 	log.Println("[Neo4j] export completed.")
 	return nil
-}
\ No newline at end of file
+}
+
+// relType quotes an edge kind for use as a Cypher relationship type; kinds
+// such as "runs-on" are not valid bare identifiers.
+func relType(kind collector.EdgeKind) string {
+	return "`" + strings.ReplaceAll(string(kind), "`", "``") + "`"
+}
@@ -0,0 +1,138 @@
+package k8sclient
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	discv1 "k8s.io/api/discovery/v1"
+	netv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/e2e-framework/klient/k8s"
+)
+
+// listedTypes pairs every object type the Lister serves with its list type.
+var listedTypes = []struct {
+	obj  runtime.Object
+	list k8s.ObjectList
+}{
+	{&appsv1.Deployment{}, &appsv1.DeploymentList{}},
+	{&appsv1.ReplicaSet{}, &appsv1.ReplicaSetList{}},
+	{&appsv1.DaemonSet{}, &appsv1.DaemonSetList{}},
+	{&appsv1.StatefulSet{}, &appsv1.StatefulSetList{}},
+	{&appsv1.ControllerRevision{}, &appsv1.ControllerRevisionList{}},
+	{&batchv1.Job{}, &batchv1.JobList{}},
+	{&batchv1.CronJob{}, &batchv1.CronJobList{}},
+	{&corev1.Pod{}, &corev1.PodList{}},
+	{&corev1.Service{}, &corev1.ServiceList{}},
+	{&corev1.PersistentVolumeClaim{}, &corev1.PersistentVolumeClaimList{}},
+	{&corev1.PersistentVolume{}, &corev1.PersistentVolumeList{}},
+	{&corev1.ConfigMap{}, &corev1.ConfigMapList{}},
+	{&corev1.Secret{}, &corev1.SecretList{}},
+	{&corev1.ServiceAccount{}, &corev1.ServiceAccountList{}},
+	{&corev1.Node{}, &corev1.NodeList{}},
+	{&corev1.Namespace{}, &corev1.NamespaceList{}},
+	{&netv1.Ingress{}, &netv1.IngressList{}},
+	{&netv1.IngressClass{}, &netv1.IngressClassList{}},
+	{&netv1.NetworkPolicy{}, &netv1.NetworkPolicyList{}},
+	{&discv1.EndpointSlice{}, &discv1.EndpointSliceList{}},
+	{&autoscalingv2.HorizontalPodAutoscaler{}, &autoscalingv2.HorizontalPodAutoscalerList{}},
+	{&policyv1.PodDisruptionBudget{}, &policyv1.PodDisruptionBudgetList{}},
+	{&rbacv1.Role{}, &rbacv1.RoleList{}},
+	{&rbacv1.ClusterRole{}, &rbacv1.ClusterRoleList{}},
+	{&rbacv1.RoleBinding{}, &rbacv1.RoleBindingList{}},
+	{&rbacv1.ClusterRoleBinding{}, &rbacv1.ClusterRoleBindingList{}},
+	{&storagev1.VolumeAttachment{}, &storagev1.VolumeAttachmentList{}},
+}
+
+// indexCache serves List calls from namespace-indexed stores, either the
+// indexers of a SharedInformerFactory or plain in-memory ones.
+type indexCache struct {
+	indexers map[reflect.Type]cache.Indexer            // list type → indexer
+	custom   map[schema.GroupVersionKind]cache.Indexer // Objects() kinds
+
+	// informer가 채우는 store는 동기화 전까지 목록 대신 ErrNotSynced를 반환
+	synced       map[reflect.Type]cache.InformerSynced
+	customSynced map[schema.GroupVersionKind]cache.InformerSynced
+}
+
+func newIndexCache() *indexCache {
+	return &indexCache{
+		indexers:     map[reflect.Type]cache.Indexer{},
+		custom:       map[schema.GroupVersionKind]cache.Indexer{},
+		synced:       map[reflect.Type]cache.InformerSynced{},
+		customSynced: map[schema.GroupVersionKind]cache.InformerSynced{},
+	}
+}
+
+// ErrNotSynced is returned for kinds whose informer has not synced, most
+// often because the service account may not list or watch them. An empty
+// cache would otherwise look like a cluster without such objects.
+var ErrNotSynced = errors.New("informer cache not synced")
+
+// indexerFor returns the indexer holding objects of obj's type, or of its
+// kind for custom objects.
+func (ic *indexCache) indexerFor(obj runtime.Object) (cache.Indexer, error) {
+	if u, ok := obj.(*unstructured.Unstructured); ok && isCustom(u) {
+		return ic.customIndexer(u.GroupVersionKind()), nil
+	}
+	t := reflect.TypeOf(obj)
+	for _, lt := range listedTypes {
+		if reflect.TypeOf(lt.obj) == t {
+			if idx, ok := ic.indexers[reflect.TypeOf(lt.list)]; ok {
+				return idx, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("%w %T", errUnsupported, obj)
+}
+
+// list copies the cached objects of into's type, restricted to ns (all
+// namespaces when empty) and sel, into into.Items.
+func (ic *indexCache) list(into k8s.ObjectList, ns string, sel labels.Selector) error {
+	idx, ok := ic.indexers[reflect.TypeOf(into)]
+	if !ok {
+		return fmt.Errorf("no store registered for %T", into)
+	}
+	if hasSynced, ok := ic.synced[reflect.TypeOf(into)]; ok && !hasSynced() {
+		return fmt.Errorf("%T: %w", into, ErrNotSynced)
+	}
+	var objs []interface{}
+	if ns == "" {
+		objs = idx.List()
+	} else {
+		var err error
+		if objs, err = idx.ByIndex(cache.NamespaceIndex, ns); err != nil {
+			return err
+		}
+	}
+
+	items := make([]runtime.Object, 0, len(objs))
+	for _, o := range objs {
+		obj, ok := o.(runtime.Object)
+		if !ok {
+			continue
+		}
+		if sel != nil && !sel.Empty() {
+			m, err := meta.Accessor(obj)
+			if err != nil || !sel.Matches(labels.Set(m.GetLabels())) {
+				continue
+			}
+		}
+		// 캐시 객체는 공유되므로 복사본을 넘긴다
+		items = append(items, obj.DeepCopyObject())
+	}
+	return meta.SetList(into, items)
+}
@@ -2,137 +2,344 @@ package k8sclient
 
 import (
 	"context"
+	"fmt"
 
 	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
 	corev1 "k8s.io/api/core/v1"
 	batchv1 "k8s.io/api/batch/v1"
+	discv1 "k8s.io/api/discovery/v1"
+	netv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/e2e-framework/klient/k8s"
 	"sigs.k8s.io/e2e-framework/klient/k8s/resources"
 	"sigs.k8s.io/e2e-framework/pkg/envconf"
 )
 
-// Client 래퍼 ---------------------------------------------------
+// Client 인터페이스 ---------------------------------------------
 
-type Client struct {
-	res *resources.Resources
-	ns  string 
+// Client is the read-only view of the cluster the collector stages use.
+type Client interface {
+	Deployments(ctx context.Context) ([]appsv1.Deployment, error)
+	ReplicaSets(ctx context.Context) ([]appsv1.ReplicaSet, error)
+	DaemonSets(ctx context.Context) ([]appsv1.DaemonSet, error)
+	StatefulSets(ctx context.Context) ([]appsv1.StatefulSet, error)
+	ControllerRevisions(ctx context.Context) ([]appsv1.ControllerRevision, error)
+	Jobs(ctx context.Context) ([]batchv1.Job, error)
+	CronJobs(ctx context.Context) ([]batchv1.CronJob, error)
+	Pods(ctx context.Context) ([]corev1.Pod, error)
+	PodsBySelector(ctx context.Context, ns string, sel map[string]string) ([]corev1.Pod, error)
+	Services(ctx context.Context) ([]corev1.Service, error)
+	PVCs(ctx context.Context) ([]corev1.PersistentVolumeClaim, error)
+	PVs(ctx context.Context) ([]corev1.PersistentVolume, error)
+	ConfigMaps(ctx context.Context) ([]corev1.ConfigMap, error)
+	Secrets(ctx context.Context) ([]corev1.Secret, error)
+	ServiceAccounts(ctx context.Context) ([]corev1.ServiceAccount, error)
+	Nodes(ctx context.Context) ([]corev1.Node, error)
+	Namespaces(ctx context.Context) ([]corev1.Namespace, error)
+	Ingresses(ctx context.Context) ([]netv1.Ingress, error)
+	IngressClasses(ctx context.Context) ([]netv1.IngressClass, error)
+	EndpointSlices(ctx context.Context) ([]discv1.EndpointSlice, error)
+	NetworkPolicies(ctx context.Context) ([]netv1.NetworkPolicy, error)
+	HorizontalPodAutoscalers(ctx context.Context) ([]autoscalingv2.HorizontalPodAutoscaler, error)
+	PodDisruptionBudgets(ctx context.Context) ([]policyv1.PodDisruptionBudget, error)
+	Roles(ctx context.Context) ([]rbacv1.Role, error)
+	ClusterRoles(ctx context.Context) ([]rbacv1.ClusterRole, error)
+	RoleBindings(ctx context.Context) ([]rbacv1.RoleBinding, error)
+	ClusterRoleBindings(ctx context.Context) ([]rbacv1.ClusterRoleBinding, error)
+	VolumeAttachments(ctx context.Context) ([]storagev1.VolumeAttachment, error)
+	Objects(ctx context.Context, gvk schema.GroupVersionKind) ([]unstructured.Unstructured, error)
 }
 
-func New(res *resources.Resources) *Client                { return &Client{res: res} }
-func (c *Client) Resources() *resources.Resources { return c.res }
-func (c *Client) Namespace(ns string) *Client             { return &Client{res: c.res.WithNamespace(ns), ns: ns} }
-func NewFromEnv(cfg *envconf.Config) *Client              { r, _ := resources.New(cfg.Client().RESTConfig()); return New(r) }
+// Lister 래퍼 ---------------------------------------------------
+
+// Lister implements Client on top of the apiserver, informer caches
+// (NewFromInformers) or in-memory objects (NewFake).
+type Lister struct {
+	res   *resources.Resources
+	cache *indexCache // nil이 아니면 apiserver 대신 캐시에서 읽음
+	ns    string 
+}
+
+var _ Client = (*Lister)(nil)
+
+func New(res *resources.Resources) *Lister                { return &Lister{res: res} }
+func (c *Lister) Resources() *resources.Resources { return c.res }
+
+// NewFromEnv builds an apiserver-backed Lister from an e2e-framework config.
+func NewFromEnv(cfg *envconf.Config) (*Lister, error) {
+	r, err := resources.New(cfg.Client().RESTConfig())
+	if err != nil {
+		return nil, fmt.Errorf("k8sclient: %w", err)
+	}
+	return New(r), nil
+}
+
+func (c *Lister) Namespace(ns string) *Lister {
+	out := &Lister{cache: c.cache, ns: ns}
+	if c.res != nil {
+		out.res = c.res.WithNamespace(ns)
+	}
+	return out
+}
+
+// list fills into from the informer cache or, without one, from the apiserver.
+func (c *Lister) list(ctx context.Context, into k8s.ObjectList, ns string, sel labels.Selector) error {
+	if ns == "" {
+		ns = c.ns
+	}
+	if c.cache != nil {
+		return c.cache.list(into, ns, sel)
+	}
+	r := c.res
+	if ns != "" {
+		r = r.WithNamespace(ns)
+	}
+	if sel == nil || sel.Empty() {
+		return r.List(ctx, into)
+	}
+	return r.List(ctx, into, resources.WithLabelSelector(sel.String()))
+}
 
 // 기본 리스트 ---------------------------------------------------
 
 // 모든 Deployment
-func (c *Client) Deployments(ctx context.Context) []appsv1.Deployment {
+func (c *Lister) Deployments(ctx context.Context) ([]appsv1.Deployment, error) {
 	var list appsv1.DeploymentList
-	_ = c.res.List(ctx, &list)
-	return list.Items
+	if err := c.list(ctx, &list, "", nil); err != nil {
+		return nil, err
+	}
+	return list.Items, nil
 }
 
 // 모든 Service
-func (c *Client) Services(ctx context.Context) []corev1.Service {
+func (c *Lister) Services(ctx context.Context) ([]corev1.Service, error) {
 	var list corev1.ServiceList
-	_ = c.res.List(ctx, &list)
-	return list.Items
+	if err := c.list(ctx, &list, "", nil); err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+// 모든 Pod
+func (c *Lister) Pods(ctx context.Context) ([]corev1.Pod, error) {
+	var list corev1.PodList
+	if err := c.list(ctx, &list, "", nil); err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+// 모든 ReplicaSet
+func (c *Lister) ReplicaSets(ctx context.Context) ([]appsv1.ReplicaSet, error) {
+	var list appsv1.ReplicaSetList
+	if err := c.list(ctx, &list, "", nil); err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+// Ingress, EndpointSlice, NetworkPolicy
+func (c *Lister) Ingresses(ctx context.Context) ([]netv1.Ingress, error) {
+	var list netv1.IngressList
+	if err := c.list(ctx, &list, "", nil); err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+func (c *Lister) IngressClasses(ctx context.Context) ([]netv1.IngressClass, error) {
+	var list netv1.IngressClassList
+	if err := c.list(ctx, &list, "", nil); err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+func (c *Lister) EndpointSlices(ctx context.Context) ([]discv1.EndpointSlice, error) {
+	var list discv1.EndpointSliceList
+	if err := c.list(ctx, &list, "", nil); err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+func (c *Lister) NetworkPolicies(ctx context.Context) ([]netv1.NetworkPolicy, error) {
+	var list netv1.NetworkPolicyList
+	if err := c.list(ctx, &list, "", nil); err != nil {
+		return nil, err
+	}
+	return list.Items, nil
 }
 
 // 라벨 셀렉터 기반 Pod
-func (c *Client) PodsBySelector(ctx context.Context, ns string, sel map[string]string) []corev1.Pod {
+func (c *Lister) PodsBySelector(ctx context.Context, ns string, sel map[string]string) ([]corev1.Pod, error) {
 	var pods corev1.PodList
-	r := c.res
-	if ns != "" { r = r.WithNamespace(ns) }
-
-	selector := labels.SelectorFromSet(sel)
-	_ = r.List(ctx, &pods, resources.WithLabelSelector(selector.String()))
-	return pods.Items
+	if err := c.list(ctx, &pods, ns, labels.SelectorFromSet(sel)); err != nil {
+		return nil, err
+	}
+	return pods.Items, nil
 }
 
 // PVC 목록
-func (c *Client) PVCs(ctx context.Context) []corev1.PersistentVolumeClaim {
+func (c *Lister) PVCs(ctx context.Context) ([]corev1.PersistentVolumeClaim, error) {
     var list corev1.PersistentVolumeClaimList
-    _ = c.Resources().List(ctx, &list)
-    return list.Items
+    if err := c.list(ctx, &list, "", nil); err != nil {
+    	return nil, err
+    }
+    return list.Items, nil
+}
+
+// PV 목록
+func (c *Lister) PVs(ctx context.Context) ([]corev1.PersistentVolume, error) {
+	var list corev1.PersistentVolumeList
+	if err := c.list(ctx, &list, "", nil); err != nil {
+		return nil, err
+	}
+	return list.Items, nil
 }
 
 // DaemonSet, StatefulSet
-func (c *Client) DaemonSets(ctx context.Context) []appsv1.DaemonSet {
+func (c *Lister) DaemonSets(ctx context.Context) ([]appsv1.DaemonSet, error) {
     var list appsv1.DaemonSetList
-    _ = c.Resources().List(ctx, &list)
-    return list.Items
+    if err := c.list(ctx, &list, "", nil); err != nil {
+    	return nil, err
+    }
+    return list.Items, nil
 }
-func (c *Client) StatefulSets(ctx context.Context) []appsv1.StatefulSet {
+func (c *Lister) StatefulSets(ctx context.Context) ([]appsv1.StatefulSet, error) {
     var list appsv1.StatefulSetList
-    _ = c.Resources().List(ctx, &list)
-    return list.Items
+    if err := c.list(ctx, &list, "", nil); err != nil {
+    	return nil, err
+    }
+    return list.Items, nil
+}
+
+// ControllerRevision (DaemonSet/StatefulSet 리비전)
+func (c *Lister) ControllerRevisions(ctx context.Context) ([]appsv1.ControllerRevision, error) {
+	var list appsv1.ControllerRevisionList
+	if err := c.list(ctx, &list, "", nil); err != nil {
+		return nil, err
+	}
+	return list.Items, nil
 }
 
 //Jobs
-func (c *Client) Jobs(ctx context.Context) []batchv1.Job {
+func (c *Lister) Jobs(ctx context.Context) ([]batchv1.Job, error) {
 	var list batchv1.JobList
-	_ = c.res.List(ctx, &list)
-	return list.Items
+	if err := c.list(ctx, &list, "", nil); err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+// CronJobs
+func (c *Lister) CronJobs(ctx context.Context) ([]batchv1.CronJob, error) {
+	var list batchv1.CronJobList
+	if err := c.list(ctx, &list, "", nil); err != nil {
+		return nil, err
+	}
+	return list.Items, nil
 }
 
 // ConfigMaps
-func (c *Client) ConfigMaps(ctx context.Context) []corev1.ConfigMap {
+func (c *Lister) ConfigMaps(ctx context.Context) ([]corev1.ConfigMap, error) {
 	var list corev1.ConfigMapList
-	_ = c.res.List(ctx, &list)
-	return list.Items
+	if err := c.list(ctx, &list, "", nil); err != nil {
+		return nil, err
+	}
+	return list.Items, nil
 }
 
 // Secrets
-func (c *Client) Secrets(ctx context.Context) []corev1.Secret {
+func (c *Lister) Secrets(ctx context.Context) ([]corev1.Secret, error) {
 	var list corev1.SecretList
-	_ = c.res.List(ctx, &list)
-	return list.Items
+	if err := c.list(ctx, &list, "", nil); err != nil {
+		return nil, err
+	}
+	return list.Items, nil
 }
 
 // ServiceAccounts
-func (c *Client) ServiceAccounts(ctx context.Context) []corev1.ServiceAccount {
+func (c *Lister) ServiceAccounts(ctx context.Context) ([]corev1.ServiceAccount, error) {
 	var list corev1.ServiceAccountList
-	_ = c.res.List(ctx, &list)
-	return list.Items
+	if err := c.list(ctx, &list, "", nil); err != nil {
+		return nil, err
+	}
+	return list.Items, nil
 }
 
-// 관계형 헬퍼 ---------------------------------------------------
-
-// Deployment → ReplicaSets
-func (c *Client) ReplicaSetsForDeployment(ctx context.Context, dp appsv1.Deployment) []appsv1.ReplicaSet {
-	var rsList appsv1.ReplicaSetList
-	_ = c.res.WithNamespace(dp.Namespace).List(ctx, &rsList)
-
-	out := make([]appsv1.ReplicaSet, 0)
-	for _, rs := range rsList.Items {
-		for _, o := range rs.OwnerReferences {
-			if o.Kind == "Deployment" && o.Name == dp.Name {
-				out = append(out, rs)
-			}
-		}
+// Nodes
+func (c *Lister) Nodes(ctx context.Context) ([]corev1.Node, error) {
+	var list corev1.NodeList
+	if err := c.list(ctx, &list, "", nil); err != nil {
+		return nil, err
 	}
-	return out
+	return list.Items, nil
 }
 
-// ReplicaSet → Pods
-func (c *Client) PodsForReplicaSet(ctx context.Context, rs appsv1.ReplicaSet) []corev1.Pod {
-	return c.PodsBySelector(ctx, rs.Namespace, rs.Spec.Selector.MatchLabels)
+// Namespaces
+func (c *Lister) Namespaces(ctx context.Context) ([]corev1.Namespace, error) {
+	var list corev1.NamespaceList
+	if err := c.list(ctx, &list, "", nil); err != nil {
+		return nil, err
+	}
+	return list.Items, nil
 }
 
-// Service → Pods
-func (c *Client) PodsForService(ctx context.Context, svc corev1.Service) []corev1.Pod {
-	return c.PodsBySelector(ctx, svc.Namespace, svc.Spec.Selector)
+// HPA, PDB
+func (c *Lister) HorizontalPodAutoscalers(ctx context.Context) ([]autoscalingv2.HorizontalPodAutoscaler, error) {
+	var list autoscalingv2.HorizontalPodAutoscalerList
+	if err := c.list(ctx, &list, "", nil); err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+func (c *Lister) PodDisruptionBudgets(ctx context.Context) ([]policyv1.PodDisruptionBudget, error) {
+	var list policyv1.PodDisruptionBudgetList
+	if err := c.list(ctx, &list, "", nil); err != nil {
+		return nil, err
+	}
+	return list.Items, nil
 }
 
-// PV name → PV* 인덱스
-func (c *Client) PVIndex(ctx context.Context) map[string]*corev1.PersistentVolume {
-    var list corev1.PersistentVolumeList
-    _ = c.Resources().List(ctx, &list)
-    out := make(map[string]*corev1.PersistentVolume)
-    for i := range list.Items {
-        out[list.Items[i].Name] = &list.Items[i]
-    }
-    return out
+// VolumeAttachments
+func (c *Lister) VolumeAttachments(ctx context.Context) ([]storagev1.VolumeAttachment, error) {
+	var list storagev1.VolumeAttachmentList
+	if err := c.list(ctx, &list, "", nil); err != nil {
+		return nil, err
+	}
+	return list.Items, nil
 }
 
+// RBAC
+func (c *Lister) Roles(ctx context.Context) ([]rbacv1.Role, error) {
+	var list rbacv1.RoleList
+	if err := c.list(ctx, &list, "", nil); err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+func (c *Lister) ClusterRoles(ctx context.Context) ([]rbacv1.ClusterRole, error) {
+	var list rbacv1.ClusterRoleList
+	if err := c.list(ctx, &list, "", nil); err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+func (c *Lister) RoleBindings(ctx context.Context) ([]rbacv1.RoleBinding, error) {
+	var list rbacv1.RoleBindingList
+	if err := c.list(ctx, &list, "", nil); err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+func (c *Lister) ClusterRoleBindings(ctx context.Context) ([]rbacv1.ClusterRoleBinding, error) {
+	var list rbacv1.ClusterRoleBindingList
+	if err := c.list(ctx, &list, "", nil); err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
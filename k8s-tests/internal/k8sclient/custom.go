@@ -0,0 +1,90 @@
+package k8sclient
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/cache"
+)
+
+// Objects lists the objects of a kind outside the client-go scheme, such as
+// Gateway API routes or operator custom resources. A kind the cluster does
+// not serve (its CRD is not installed) has no objects rather than failing.
+// Cache-backed Listers only see kinds registered with WatchCustom.
+func (c *Lister) Objects(ctx context.Context, gvk schema.GroupVersionKind) ([]unstructured.Unstructured, error) {
+	if c.cache != nil {
+		return c.cache.listCustom(gvk, c.ns)
+	}
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+	r := c.res
+	if c.ns != "" {
+		r = r.WithNamespace(c.ns)
+	}
+	if err := r.List(ctx, list); err != nil {
+		if meta.IsNoMatchError(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+// WatchCustom makes a cache-backed Lister serve gvk from inf, typically a
+// dynamic informer. Call it before the informer is started.
+func (c *Lister) WatchCustom(gvk schema.GroupVersionKind, inf cache.SharedIndexInformer) {
+	if c.cache == nil {
+		return
+	}
+	c.cache.custom[gvk] = inf.GetIndexer()
+	c.cache.customSynced[gvk] = inf.HasSynced
+}
+
+// customIndexer returns the store for gvk, creating an in-memory one.
+func (ic *indexCache) customIndexer(gvk schema.GroupVersionKind) cache.Indexer {
+	idx, ok := ic.custom[gvk]
+	if !ok {
+		idx = cache.NewIndexer(cache.MetaNamespaceKeyFunc,
+			cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+		ic.custom[gvk] = idx
+	}
+	return idx
+}
+
+// listCustom copies the cached objects of gvk in ns (all namespaces when
+// empty). Kinds nothing was stored for have no objects.
+func (ic *indexCache) listCustom(gvk schema.GroupVersionKind, ns string) ([]unstructured.Unstructured, error) {
+	idx, ok := ic.custom[gvk]
+	if !ok {
+		return nil, nil
+	}
+	if hasSynced, ok := ic.customSynced[gvk]; ok && !hasSynced() {
+		return nil, fmt.Errorf("%s: %w", gvk.Kind, ErrNotSynced)
+	}
+	var objs []interface{}
+	if ns == "" {
+		objs = idx.List()
+	} else {
+		var err error
+		if objs, err = idx.ByIndex(cache.NamespaceIndex, ns); err != nil {
+			return nil, err
+		}
+	}
+	out := make([]unstructured.Unstructured, 0, len(objs))
+	for _, o := range objs {
+		if u, ok := o.(*unstructured.Unstructured); ok {
+			out = append(out, *u.DeepCopy())
+		}
+	}
+	return out, nil
+}
+
+// isCustom reports whether u is a kind Objects serves: one with an API
+// group outside the client-go scheme.
+func isCustom(u *unstructured.Unstructured) bool {
+	return strings.Contains(u.GetAPIVersion(), "/") && u.GetKind() != ""
+}
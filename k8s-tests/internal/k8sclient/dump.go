@@ -0,0 +1,130 @@
+package k8sclient
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+// errUnsupported marks objects the Lister has no store for.
+var errUnsupported = errors.New("unsupported object type")
+
+// NewFromDump returns an in-memory Client holding the objects captured in
+// path. path is either one file or a directory walked recursively; every
+// .json, .yaml and .yml file is read. Files may hold single objects, List
+// documents (`kubectl get -o json`) or typed lists such as the PodList
+// files `kubectl cluster-info dump --output-directory` writes. Custom
+// resources are kept for Objects; built-in kinds the collector does not use
+// (Events) and non-object files (logs) are skipped.
+func NewFromDump(path string) (*Fake, error) {
+	f, err := NewFake()
+	if err != nil {
+		return nil, err
+	}
+	err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		switch strings.ToLower(filepath.Ext(p)) {
+		case ".json", ".yaml", ".yml":
+		default:
+			return nil
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		objs, err := decodeObjects(data, false)
+		if err != nil {
+			return fmt.Errorf("%s: %w", p, err)
+		}
+		for _, obj := range objs {
+			if err := f.Upsert(obj); err != nil && !errors.Is(err, errUnsupported) {
+				return fmt.Errorf("%s: %w", p, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// decodeObjects decodes every document of a YAML or JSON stream into typed
+// objects, flattening List documents. Items of typed lists inherit the
+// list's kind. Kinds with an API group unknown to the client-go scheme are
+// returned as *unstructured.Unstructured; other decoding failures are an
+// error when strict, and skipped otherwise.
+func decodeObjects(data []byte, strict bool) ([]runtime.Object, error) {
+	dec := utilyaml.NewYAMLOrJSONDecoder(bytes.NewReader(data), 4096)
+	var objs []runtime.Object
+	for doc := 1; ; doc++ {
+		var raw map[string]interface{}
+		if err := dec.Decode(&raw); err != nil {
+			if errors.Is(err, io.EOF) {
+				return objs, nil
+			}
+			return nil, fmt.Errorf("document %d: %w", doc, err)
+		}
+		if len(raw) == 0 {
+			continue
+		}
+		items := []unstructured.Unstructured{{Object: raw}}
+		if u := items[0]; u.IsList() {
+			list, err := u.ToList()
+			if err != nil {
+				return nil, fmt.Errorf("document %d: %w", doc, err)
+			}
+			items = list.Items
+			itemKind := strings.TrimSuffix(u.GetKind(), "List")
+			for i := range items {
+				if items[i].GetKind() == "" && itemKind != "" {
+					items[i].SetAPIVersion(u.GetAPIVersion())
+					items[i].SetKind(itemKind)
+				}
+			}
+		}
+		for i := range items {
+			obj, err := typed(&items[i])
+			if runtime.IsNotRegisteredError(err) && isCustom(&items[i]) {
+				objs = append(objs, &items[i])
+				continue
+			}
+			if err != nil {
+				if strict {
+					return nil, fmt.Errorf("document %d: %w", doc, err)
+				}
+				continue
+			}
+			objs = append(objs, obj)
+		}
+	}
+}
+
+// typed converts u into the scheme's Go type for its kind.
+func typed(u *unstructured.Unstructured) (runtime.Object, error) {
+	gvk := u.GroupVersionKind()
+	obj, err := scheme.Scheme.New(gvk)
+	if err != nil {
+		return nil, err
+	}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, obj); err != nil {
+		return nil, fmt.Errorf("%s %s/%s: %w", gvk.Kind, u.GetNamespace(), u.GetName(), err)
+	}
+	obj.GetObjectKind().SetGroupVersionKind(gvk)
+	return obj, nil
+}
@@ -0,0 +1,64 @@
+package k8sclient
+
+import (
+	"reflect"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/cache"
+)
+
+// Fake is an in-memory Client for stage tests. Objects are served exactly as
+// stored; nothing fills in defaults or runs controllers.
+type Fake struct {
+	*Lister
+}
+
+// NewFake returns a Fake holding objs.
+func NewFake(objs ...runtime.Object) (*Fake, error) {
+	ic := newIndexCache()
+	for _, lt := range listedTypes {
+		ic.indexers[reflect.TypeOf(lt.list)] = cache.NewIndexer(cache.MetaNamespaceKeyFunc,
+			cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	}
+	f := &Fake{Lister: &Lister{cache: ic}}
+	for _, obj := range objs {
+		if err := f.Upsert(obj); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+// NewFakeFromYAML returns a Fake holding the objects of a multi-document
+// YAML (or JSON) manifest.
+func NewFakeFromYAML(data []byte) (*Fake, error) {
+	objs, err := DecodeManifest(data)
+	if err != nil {
+		return nil, err
+	}
+	return NewFake(objs...)
+}
+
+// Upsert stores obj, replacing any object with the same namespace and name.
+func (f *Fake) Upsert(obj runtime.Object) error {
+	idx, err := f.cache.indexerFor(obj)
+	if err != nil {
+		return err
+	}
+	return idx.Update(obj)
+}
+
+// Delete removes obj from the store.
+func (f *Fake) Delete(obj runtime.Object) error {
+	idx, err := f.cache.indexerFor(obj)
+	if err != nil {
+		return err
+	}
+	return idx.Delete(obj)
+}
+
+// DecodeManifest decodes every document of a YAML (or JSON) manifest into
+// typed objects using the client-go scheme. List documents are flattened.
+func DecodeManifest(data []byte) ([]runtime.Object, error) {
+	return decodeObjects(data, true)
+}
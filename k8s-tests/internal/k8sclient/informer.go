@@ -0,0 +1,59 @@
+package k8sclient
+
+import (
+	"reflect"
+
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	discv1 "k8s.io/api/discovery/v1"
+	netv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/e2e-framework/klient/k8s"
+)
+
+// NewFromInformers returns a Lister that reads from factory's caches, so a
+// stage run costs no apiserver round-trips once the caches are synced. It
+// registers an informer for every kind the Lister serves, so call it before
+// factory.Start; the caller is still responsible for WaitForCacheSync. Kinds
+// whose informer has not synced fail with ErrNotSynced.
+func NewFromInformers(factory informers.SharedInformerFactory) *Lister {
+	ic := newIndexCache()
+	reg := func(list k8s.ObjectList, inf cache.SharedIndexInformer) {
+		ic.indexers[reflect.TypeOf(list)] = inf.GetIndexer()
+		ic.synced[reflect.TypeOf(list)] = inf.HasSynced
+	}
+	reg(&appsv1.DeploymentList{}, factory.Apps().V1().Deployments().Informer())
+	reg(&appsv1.ReplicaSetList{}, factory.Apps().V1().ReplicaSets().Informer())
+	reg(&appsv1.DaemonSetList{}, factory.Apps().V1().DaemonSets().Informer())
+	reg(&appsv1.StatefulSetList{}, factory.Apps().V1().StatefulSets().Informer())
+	reg(&appsv1.ControllerRevisionList{}, factory.Apps().V1().ControllerRevisions().Informer())
+	reg(&batchv1.JobList{}, factory.Batch().V1().Jobs().Informer())
+	reg(&batchv1.CronJobList{}, factory.Batch().V1().CronJobs().Informer())
+	reg(&corev1.PodList{}, factory.Core().V1().Pods().Informer())
+	reg(&corev1.ServiceList{}, factory.Core().V1().Services().Informer())
+	reg(&corev1.PersistentVolumeClaimList{}, factory.Core().V1().PersistentVolumeClaims().Informer())
+	reg(&corev1.PersistentVolumeList{}, factory.Core().V1().PersistentVolumes().Informer())
+	reg(&corev1.ConfigMapList{}, factory.Core().V1().ConfigMaps().Informer())
+	reg(&corev1.SecretList{}, factory.Core().V1().Secrets().Informer())
+	reg(&corev1.ServiceAccountList{}, factory.Core().V1().ServiceAccounts().Informer())
+	reg(&corev1.NodeList{}, factory.Core().V1().Nodes().Informer())
+	reg(&corev1.NamespaceList{}, factory.Core().V1().Namespaces().Informer())
+	reg(&netv1.IngressList{}, factory.Networking().V1().Ingresses().Informer())
+	reg(&netv1.IngressClassList{}, factory.Networking().V1().IngressClasses().Informer())
+	reg(&netv1.NetworkPolicyList{}, factory.Networking().V1().NetworkPolicies().Informer())
+	reg(&discv1.EndpointSliceList{}, factory.Discovery().V1().EndpointSlices().Informer())
+	reg(&autoscalingv2.HorizontalPodAutoscalerList{}, factory.Autoscaling().V2().HorizontalPodAutoscalers().Informer())
+	reg(&policyv1.PodDisruptionBudgetList{}, factory.Policy().V1().PodDisruptionBudgets().Informer())
+	reg(&rbacv1.RoleList{}, factory.Rbac().V1().Roles().Informer())
+	reg(&rbacv1.ClusterRoleList{}, factory.Rbac().V1().ClusterRoles().Informer())
+	reg(&rbacv1.RoleBindingList{}, factory.Rbac().V1().RoleBindings().Informer())
+	reg(&rbacv1.ClusterRoleBindingList{}, factory.Rbac().V1().ClusterRoleBindings().Informer())
+	reg(&storagev1.VolumeAttachmentList{}, factory.Storage().V1().VolumeAttachments().Informer())
+	return &Lister{cache: ic}
+}
@@ -0,0 +1,84 @@
+package k8sclient
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func TestInformerClientReadsFromCache(t *testing.T) {
+	pod := func(ns, name, app string) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Namespace: ns, Name: name, Labels: map[string]string{"app": app},
+		}}
+	}
+	cs := fake.NewSimpleClientset(
+		pod("openstack", "keystone-api-0", "keystone"),
+		pod("openstack", "nova-api-0", "nova"),
+		pod("ceph", "keystone-probe", "keystone"),
+	)
+	factory := informers.NewSharedInformerFactory(cs, 0)
+	c := NewFromInformers(factory)
+	stop := make(chan struct{})
+	defer close(stop)
+	factory.Start(stop)
+	factory.WaitForCacheSync(stop)
+
+	ctx := context.Background()
+	if pods, err := c.Pods(ctx); err != nil || len(pods) != 3 {
+		t.Fatalf("Pods() = %d, %v, want 3", len(pods), err)
+	}
+	if pods, err := c.Namespace("openstack").Pods(ctx); err != nil || len(pods) != 2 {
+		t.Fatalf("Namespace(openstack).Pods() = %d, %v, want 2", len(pods), err)
+	}
+	got, err := c.PodsBySelector(ctx, "openstack", map[string]string{"app": "keystone"})
+	if err != nil || len(got) != 1 || got[0].Name != "keystone-api-0" {
+		t.Fatalf("PodsBySelector = %v, want [keystone-api-0]", got)
+	}
+
+	// 클러스터 요청이 한 번도 없어야 함: 초기 list/watch 이외의 액션은 없음
+	before := len(cs.Actions())
+	c.Services(ctx)
+	c.Deployments(ctx)
+	if after := len(cs.Actions()); after != before {
+		t.Fatalf("cache-backed reads issued %d apiserver calls", after-before)
+	}
+}
+
+// A kind the service account may not list never syncs; the Lister reports
+// it instead of serving an empty cache.
+func TestInformerClientReportsUnsyncedKinds(t *testing.T) {
+	cs := fake.NewSimpleClientset(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "openstack", Name: "keystone-api-0"}})
+	cs.PrependReactor("list", "secrets", func(clienttesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewForbidden(corev1.Resource("secrets"), "", errors.New("rbac"))
+	})
+	factory := informers.NewSharedInformerFactory(cs, 0)
+	c := NewFromInformers(factory)
+	stop := make(chan struct{})
+	defer close(stop)
+	factory.Start(stop)
+	wait, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	synced := factory.WaitForCacheSync(wait.Done())
+	if synced[reflect.TypeOf(&corev1.Secret{})] {
+		t.Fatal("secrets synced despite the forbidden list")
+	}
+
+	ctx := context.Background()
+	if pods, err := c.Pods(ctx); err != nil || len(pods) != 1 {
+		t.Fatalf("Pods() = %d, %v, want 1", len(pods), err)
+	}
+	if _, err := c.Secrets(ctx); !errors.Is(err, ErrNotSynced) {
+		t.Fatalf("Secrets() error = %v, want ErrNotSynced", err)
+	}
+}
@@ -2,9 +2,7 @@ package main
 
 import (
 	"context"
-	"encoding/csv"
 	"flag"
-	"fmt"
 	"log"
 	"path/filepath"
 	"time"
@@ -18,6 +16,7 @@ import (
 	"sigs.k8s.io/e2e-framework/pkg/envconf"
 
 	"github.com/kaist2025/k8s-e2e-tests/internal/collector"
+	"github.com/kaist2025/k8s-e2e-tests/internal/exporter"
 	"github.com/kaist2025/k8s-e2e-tests/internal/k8sclient"
 )
 
@@ -49,7 +48,10 @@ func main() {
 
 	// 2. Wrap in e2e-framework client
 	envCfg := envconf.NewWithKubeConfig(configPath)
-	k8sCli := k8sclient.NewFromEnv(envCfg)
+	k8sCli, err := k8sclient.NewFromEnv(envCfg)
+	if err != nil {
+		log.Fatalf("error creating collector client: %v", err)
+	}
 	coll := &collector.Collector{
 		Client: k8sCli,
 		Stages: []collector.Stage{
@@ -136,17 +138,18 @@ func main() {
 
 func runOnce(ctx context.Context, coll *collector.Collector, dir string) {
 	log.Printf(">> Running collector")
-	g, err := coll.Run(ctx)
+	res, err := coll.Run(ctx)
 	if err != nil {
 		log.Printf("collector error: %v", err)
 		return
 	}
+	snap := res.Graph.Snapshot()
 	//save mermaid
-	if err := saveMermaid(g, dir); err != nil {
+	if err := saveMermaid(snap, dir); err != nil {
 		log.Printf("saveMermaid error: %v", err)
 	}
 	//save csv
-	if err := saveCSV(g, dir); err != nil {
+	if err := saveCSV(snap, dir); err != nil {
 		log.Printf("saveCSV error: %v", err)
 	}
 	log.Printf("<< Collector completed")
@@ -155,7 +158,7 @@ func runOnce(ctx context.Context, coll *collector.Collector, dir string) {
 
 // Save 
 
-func saveMermaid(g *collector.Graph, dir string) error {
+func saveMermaid(g *collector.Snapshot, dir string) error {
 	err := os.MkdirAll(dir, 0o755)
 	if err != nil {
 		return err
@@ -165,52 +168,25 @@ func saveMermaid(g *collector.Graph, dir string) error {
 		return err
 	}
 	defer f.Close()
-	fmt.Fprintln(f, "graph LR")
-	for _, e := range g.Edges {
-		fmt.Fprintf(f, "%s[\"%s\"] -->|%s| %s[\"%s\"]\n",
-			e.From, g.Nodes[e.From].Label, e.Kind, e.To, g.Nodes[e.To].Label)
-	}
-	return nil
+	return exporter.WriteMermaid(g, f)
 }
 
-func saveCSV(g *collector.Graph, dir string) error {
-	// 1) nodes.csv
+func saveCSV(g *collector.Snapshot, dir string) error {
 	if err := os.MkdirAll(dir, 0o755); err != nil {
 		return err
 	}
-	fn1 := filepath.Join(dir, "nodes.csv")
-	f1, err := os.Create(fn1)
+	f1, err := os.Create(filepath.Join(dir, "nodes.csv"))
 	if err != nil {
 		return err
 	}
 	defer f1.Close()
-	w1 := csv.NewWriter(f1)
-	if err := w1.Write([]string{"UID", "Label", "Type", "NS"}); err != nil {
+	if err := exporter.WriteNodesCSV(g, f1); err != nil {
 		return err
 	}
-	for _, n := range g.Nodes {
-		if err := w1.Write([]string{n.UID, n.Label, n.Type, n.NS}); err != nil {
-			return err
-		}
-	}
-	w1.Flush()
-
-	// 2) edges.csv
-	fn2 := filepath.Join(dir, "edges.csv")
-	f2, err := os.Create(fn2)
+	f2, err := os.Create(filepath.Join(dir, "edges.csv"))
 	if err != nil {
 		return err
 	}
 	defer f2.Close()
-	w2 := csv.NewWriter(f2)
-	if err := w2.Write([]string{"FromUID", "ToUID", "Kind"}); err != nil {
-		return err
-	}
-	for _, e := range g.Edges {
-		if err := w2.Write([]string{e.From, e.To, string(e.Kind)}); err != nil {
-			return err
-		}
-	}
-	w2.Flush()
-	return nil
-}
\ No newline at end of file
+	return exporter.WriteEdgesCSV(g, f2)
+}